@@ -1,58 +1,73 @@
 package authn
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
+	"golang.org/x/crypto/ssh"
 	"google.golang.org/protobuf/proto"
 )
 
-func VerifyAlfaSignature(signatureBase64 string, timestamp int64, now time.Time, publicPem string, request proto.Message, service string, method string) error {
+// Alfa API keys are asymmetric key pairs. A private key can be either ECDSA P-256 or Ed25519, the algorithm is
+// selected by the type of the key in PEM. Signatures are Base64 encoded (ASN.1 for ECDSA, raw for Ed25519).
+
+func VerifyAlfaSignature(signatureBase64 string, timestamp int64, now time.Time, publicPem string, request proto.Message, service string, method string, opts ...VerifyOption) error {
+	o := newVerifyOptions(opts)
+
 	// Check timestamp for replays
-	ts := time.Unix(timestamp, 0)
-	if now.Add(time.Minute*5).Before(ts) || now.Add(time.Minute*-5).After(ts) {
-		return errors.New("invalid timestamp")
+	err := checkTimestamp(timestamp, now, o)
+	if err != nil {
+		return err
 	}
 
 	// Decode signature from Base64
-	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	signature, err := decodeCanonicalBase64(base64.StdEncoding, signatureBase64)
 	if err != nil {
-		return err
+		return malformedSignature(err)
 	}
 
 	// Serialize timestamp and request body
-	requestBytes, err := serializeRequest(request)
+	data, err := signaturePayload(timestamp, request, service, method, o.headers)
 	if err != nil {
 		return err
 	}
-	timestampBytes := serializeTimestamp(timestamp)
 
-	data := append(timestampBytes, []byte(fmt.Sprintf("%s.%s", service, method))...)
-	data = append(data, requestBytes...)
+	return verifyAlfaData(data, signature, publicPem)
+}
 
+// verifyAlfaData verifies a signature of data with an Alfa public key
+func verifyAlfaData(data []byte, signature []byte, publicPem string) error {
 	// Deserialize public PEM string
-	block, _ := pem.Decode([]byte(publicPem))
-
-	// Deserialize ECDSA public key
-	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	publicKey, err := ParseAlfaPublicKey(publicPem)
 	if err != nil {
 		return err
 	}
-	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return errors.New("not an ECDSA public key")
-	}
 
-	// Verify timestamped request
-	return VerifyP256(data, signature, ecdsaPublicKey)
+	// Verify data with the algorithm of the key
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return VerifyP256(data, signature, publicKey)
+	case ed25519.PublicKey:
+		return VerifyEd25519(data, signature, publicKey)
+	default:
+		return fmt.Errorf("%w: unsupported public key type", ErrMalformedKey)
+	}
 }
 
+// GenerateAlfaKeys generates a new ECDSA P-256 Alfa key pair
 func GenerateAlfaKeys() (privatePem string, publicPem string, err error) {
 	privateKey, publicKey, err := GenerateP256KeyPair()
 	if err != nil {
@@ -77,28 +92,136 @@ func GenerateAlfaKeys() (privatePem string, publicPem string, err error) {
 	return
 }
 
-func SignAlfa(timestamp int64, privatePem string, request proto.Message, service string, method string) (string, error) {
-	// Serialize timestamp and request body
-	requestBytes, err := serializeRequest(request)
+// GenerateAlfaEd25519Keys generates a new Ed25519 Alfa key pair. Private key is encoded as PKCS#8 PEM ("PRIVATE KEY"
+// block).
+func GenerateAlfaEd25519Keys() (privatePem string, publicPem string, err error) {
+	privateKey, publicKey, err := GenerateEd25519KeyPair()
 	if err != nil {
-		return "", err
+		return
 	}
-	timestampBytes := serializeTimestamp(timestamp)
 
-	data := append(timestampBytes, []byte(fmt.Sprintf("%s.%s", service, method))...)
-	data = append(data, requestBytes...)
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return
+	}
+
+	privateBlock := &pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}
+	privatePem = string(pem.EncodeToMemory(privateBlock))
 
+	publicBlock := &pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}
+	publicPem = string(pem.EncodeToMemory(publicBlock))
+
+	return
+}
+
+// ParseAlfaPrivateKey parses an Alfa private key from a PEM string: "EC PRIVATE KEY" block with a P-256 key, PKCS#8
+// "PRIVATE KEY" block or unencrypted "OPENSSH PRIVATE KEY" block (as written by ssh-keygen) with a P-256 or Ed25519
+// key
+func ParseAlfaPrivateKey(privatePem string) (crypto.Signer, error) {
 	// Deserialize private PEM string
 	block, _ := pem.Decode([]byte(privatePem))
+	if block == nil {
+		return nil, fmt.Errorf("%w: invalid private PEM", ErrMalformedKey)
+	}
+
+	var privateKey any
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "OPENSSH PRIVATE KEY":
+		privateKey, err = ssh.ParseRawPrivateKey([]byte(privatePem))
+		if key, ok := privateKey.(*ed25519.PrivateKey); ok {
+			privateKey = *key
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported private PEM type %s", ErrMalformedKey, block.Type)
+	}
+	if err != nil {
+		return nil, malformedKey(err)
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an ECDSA or Ed25519 private key", ErrMalformedKey)
+	}
+
+	err = ValidateAlfaKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// ParseAlfaPublicKey parses an Alfa public key from a PKIX PEM string ("PUBLIC KEY" block with a P-256 or Ed25519
+// key)
+func ParseAlfaPublicKey(publicPem string) (crypto.PublicKey, error) {
+	// Deserialize public PEM string
+	block, _ := pem.Decode([]byte(publicPem))
+	if block == nil {
+		return nil, fmt.Errorf("%w: invalid public PEM", ErrMalformedKey)
+	}
 
-	// Deserialize ECDSA private key
-	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, malformedKey(err)
+	}
+
+	err = validateAlfaPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicKey, nil
+}
+
+// ValidateAlfaKey checks that a private key (possibly stored in an HSM or KMS) can be used to sign requests with Alfa
+// API keys, i.e. it is a P-256 or Ed25519 key
+func ValidateAlfaKey(privateKey crypto.Signer) error {
+	return validateAlfaPublicKey(privateKey.Public())
+}
+
+func validateAlfaPublicKey(publicKey crypto.PublicKey) error {
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if publicKey.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: not a P-256 key", ErrMalformedKey)
+		}
+		return nil
+	case ed25519.PublicKey:
+		return nil
+	default:
+		return fmt.Errorf("%w: not an ECDSA or Ed25519 key", ErrMalformedKey)
+	}
+}
+
+func SignAlfa(timestamp int64, privatePem string, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	privateKey, err := ParseAlfaPrivateKey(privatePem)
 	if err != nil {
 		return "", err
 	}
 
-	// Sign
-	signature, err := SignP256(data, privateKey)
+	return SignAlfaWithKey(timestamp, privateKey, request, service, method, opts...)
+}
+
+// SignAlfaWithKey is the same as SignAlfa, but takes an already parsed private key (see ParseAlfaPrivateKey) or any
+// other crypto.Signer with a P-256 or Ed25519 key, so the private key can live in an HSM, a PKCS#11 token or a KMS.
+// For ECDSA keys only a SHA-256 digest of the payload is passed to the signer.
+func SignAlfaWithKey(timestamp int64, privateKey crypto.Signer, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	// Serialize timestamp and request body
+	data, err := signaturePayload(timestamp, request, service, method, newSignOptions(opts).headers)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signAlfaData(data, privateKey)
 	if err != nil {
 		return "", err
 	}
@@ -109,9 +232,81 @@ func SignAlfa(timestamp int64, privatePem string, request proto.Message, service
 	return signatureBase64, nil
 }
 
-// serializeRequest serializes protobuf body in a deterministic way
+// signAlfaData signs data with the algorithm of an Alfa private key
+func signAlfaData(data []byte, privateKey crypto.Signer) ([]byte, error) {
+	var signature []byte
+	var err error
+	switch publicKey := privateKey.Public().(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(data)
+		signature, err = privateKey.Sign(rand.Reader, hash[:], crypto.SHA256)
+		if err == nil {
+			signature, err = encodeECDSASignature(signature, publicKey)
+		}
+	case ed25519.PublicKey:
+		signature, err = privateKey.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		err = errors.New("unsupported private key type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// encodeECDSASignature makes sure an ECDSA signature is ASN.1 encoded. Some HSMs and PKCS#11 tokens return raw
+// signatures (r || s), those are converted to ASN.1.
+func encodeECDSASignature(signature []byte, publicKey *ecdsa.PublicKey) ([]byte, error) {
+	sig := ECDSASignature{}
+	rest, err := asn1.Unmarshal(signature, &sig)
+	if err == nil && len(rest) == 0 {
+		return signature, nil
+	}
+
+	size := (publicKey.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return nil, errors.New("malformed ECDSA signature")
+	}
+
+	return asn1.Marshal(ECDSASignature{
+		R: new(big.Int).SetBytes(signature[:size]),
+		S: new(big.Int).SetBytes(signature[size:]),
+	})
+}
+
+// signaturePayload builds signed data. Without headers it is version 1 payload: timestamp, "Service.Method" and
+// request body. With headers (even empty) it is version 2 payload, which also has signed headers.
+func signaturePayload(timestamp int64, request proto.Message, service string, method string, headers map[string][]string) ([]byte, error) {
+	requestBytes, err := serializeRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	timestampBytes := serializeTimestamp(timestamp)
+
+	if headers == nil {
+		data := append(timestampBytes, []byte(fmt.Sprintf("%s.%s", service, method))...)
+		data = append(data, requestBytes...)
+		return data, nil
+	}
+
+	headersBytes, err := canonicalHeaders(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(signatureV2Prefix)
+	data = append(data, timestampBytes...)
+	data = appendLengthPrefixed(data, fmt.Sprintf("%s.%s", service, method))
+	data = append(data, headersBytes...)
+	data = append(data, requestBytes...)
+
+	return data, nil
+}
+
+// serializeRequest serializes protobuf body in the canonical form (see CanonicalRequest)
 func serializeRequest(request proto.Message) ([]byte, error) {
-	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	data, err := CanonicalRequest(request)
 	if err != nil {
 		return nil, err
 	}
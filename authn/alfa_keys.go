@@ -0,0 +1,208 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JWK is a JSON Web Key (RFC 7517) of an Alfa key: "EC" key on "P-256" curve or "OKP" key on "Ed25519" curve (RFC
+// 8037). D is set only for private keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+}
+
+// ImportAlfaPrivateKey converts a private key in any format supported by ParseAlfaPrivateKey (SEC1, PKCS#8 or
+// OpenSSH) to the format produced by GenerateAlfaKeys and GenerateAlfaEd25519Keys: "EC PRIVATE KEY" block for P-256
+// keys and PKCS#8 "PRIVATE KEY" block for Ed25519 keys
+func ImportAlfaPrivateKey(privateKey string) (privatePem string, err error) {
+	signer, err := ParseAlfaPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	var block *pem.Block
+	switch signer := signer.(type) {
+	case *ecdsa.PrivateKey:
+		privateKeyBytes, err := x509.MarshalECPrivateKey(signer)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: privateKeyBytes}
+	case ed25519.PrivateKey:
+		privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(signer)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}
+	default:
+		return "", fmt.Errorf("%w: not an ECDSA or Ed25519 private key", ErrMalformedKey)
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// ImportAlfaPublicKey converts a public key, either a PKIX "PUBLIC KEY" PEM or an OpenSSH authorized_keys line
+// ("ecdsa-sha2-nistp256 AAAA..." or "ssh-ed25519 AAAA..."), to a PKIX PEM as expected by IAM CreateAlfaKeyRequest
+func ImportAlfaPublicKey(publicKey string) (publicPem string, err error) {
+	if strings.HasPrefix(strings.TrimSpace(publicKey), "-----BEGIN") {
+		key, err := ParseAlfaPublicKey(publicKey)
+		if err != nil {
+			return "", err
+		}
+		return MarshalAlfaPublicKey(key)
+	}
+
+	sshKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", malformedKey(err)
+	}
+	cryptoKey, ok := sshKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported SSH key type %s", ErrMalformedKey, sshKey.Type())
+	}
+
+	return MarshalAlfaPublicKey(cryptoKey.CryptoPublicKey())
+}
+
+// MarshalAlfaPublicKey encodes a P-256 or Ed25519 public key as a PKIX PEM string ("PUBLIC KEY" block)
+func MarshalAlfaPublicKey(publicKey crypto.PublicKey) (string, error) {
+	err := validateAlfaPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	publicBlock := &pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}
+	return string(pem.EncodeToMemory(publicBlock)), nil
+}
+
+// DeriveAlfaPublicKey derives a PKIX public PEM from an Alfa private key in any format supported by
+// ParseAlfaPrivateKey
+func DeriveAlfaPublicKey(privatePem string) (publicPem string, err error) {
+	privateKey, err := ParseAlfaPrivateKey(privatePem)
+	if err != nil {
+		return "", err
+	}
+
+	return MarshalAlfaPublicKey(privateKey.Public())
+}
+
+// AlfaKeyFingerprint computes a fingerprint of an Alfa public PEM: "SHA256:" followed by unpadded Base64 of SHA-256 of
+// DER encoded SubjectPublicKeyInfo. It does not depend on PEM formatting (line breaks, headers), so a key registered
+// in IAM can be matched with a local file.
+func AlfaKeyFingerprint(publicPem string) (string, error) {
+	publicKey, err := ParseAlfaPublicKey(publicPem)
+	if err != nil {
+		return "", err
+	}
+
+	return alfaKeyFingerprint(publicKey)
+}
+
+func alfaKeyFingerprint(publicKey crypto.PublicKey) (string, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(publicKeyBytes)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:]), nil
+}
+
+// ExportAlfaPublicJWK exports an Alfa public PEM as a JWK. Kid is the fingerprint of the key (see
+// AlfaKeyFingerprint).
+func ExportAlfaPublicJWK(publicPem string) (*JWK, error) {
+	publicKey, err := ParseAlfaPublicKey(publicPem)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicJWK(publicKey)
+}
+
+// ExportAlfaPrivateJWK exports an Alfa private key in any format supported by ParseAlfaPrivateKey as a JWK with the
+// private part. Kid is the fingerprint of the public key (see AlfaKeyFingerprint).
+func ExportAlfaPrivateJWK(privatePem string) (*JWK, error) {
+	privateKey, err := ParseAlfaPrivateKey(privatePem)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := publicJWK(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		ecdhKey, err := privateKey.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		jwk.D = base64.RawURLEncoding.EncodeToString(ecdhKey.Bytes())
+	case ed25519.PrivateKey:
+		jwk.D = base64.RawURLEncoding.EncodeToString(privateKey.Seed())
+	default:
+		return nil, fmt.Errorf("%w: private key can't be exported", ErrMalformedKey)
+	}
+
+	return jwk, nil
+}
+
+func publicJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	kid, err := alfaKeyFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		// Uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		return &JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+			Alg: "ES256",
+			Use: "sig",
+			Kid: kid,
+		}, nil
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			Alg: "EdDSA",
+			Use: "sig",
+			Kid: kid,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: not an ECDSA or Ed25519 key", ErrMalformedKey)
+	}
+}
@@ -0,0 +1,187 @@
+package authn
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestImportAlfaPrivateKey(t *testing.T) {
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(t, err)
+		privateKey, err := ParseAlfaPrivateKey(privatePem)
+		require.NoError(t, err)
+
+		// PKCS#8
+		pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		require.NoError(t, err)
+		pkcs8Pem := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes}))
+
+		// OpenSSH
+		opensshBlock, err := ssh.MarshalPrivateKey(privateKey, "test")
+		require.NoError(t, err)
+		opensshPem := string(pem.EncodeToMemory(opensshBlock))
+
+		for _, imported := range []string{privatePem, pkcs8Pem, opensshPem} {
+			importedPem, err := ImportAlfaPrivateKey(imported)
+			require.NoError(t, err)
+			require.Equal(t, privatePem, importedPem)
+
+			derivedPem, err := DeriveAlfaPublicKey(imported)
+			require.NoError(t, err)
+			require.Equal(t, publicPem, derivedPem)
+
+			// Imported keys sign requests directly
+			signature, err := SignAlfa(1733240571, imported, wrapperspb.String("my_queue"), "Moab", "GetQueue")
+			require.NoError(t, err)
+			err = VerifyAlfaSignature(signature, 1733240571, time.Unix(1733240571, 0), publicPem, wrapperspb.String("my_queue"), "Moab", "GetQueue")
+			require.NoError(t, err)
+		}
+	}
+
+	// Encrypted OpenSSH keys are not supported
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	encryptedBlock, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "test", []byte("passphrase"))
+	require.NoError(t, err)
+	_, err = ImportAlfaPrivateKey(string(pem.EncodeToMemory(encryptedBlock)))
+	require.ErrorIs(t, err, ErrMalformedKey)
+
+	// RSA keys are not Alfa keys
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaBlock, err := ssh.MarshalPrivateKey(rsaKey, "test")
+	require.NoError(t, err)
+	_, err = ImportAlfaPrivateKey(string(pem.EncodeToMemory(rsaBlock)))
+	require.ErrorIs(t, err, ErrMalformedKey)
+}
+
+func TestImportAlfaPublicKey(t *testing.T) {
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(t, err)
+		privateKey, err := ParseAlfaPrivateKey(privatePem)
+		require.NoError(t, err)
+
+		sshKey, err := ssh.NewPublicKey(privateKey.Public())
+		require.NoError(t, err)
+		authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshKey))) + " user@host\n"
+
+		for _, imported := range []string{publicPem, authorizedKey} {
+			importedPem, err := ImportAlfaPublicKey(imported)
+			require.NoError(t, err)
+			require.Equal(t, publicPem, importedPem)
+		}
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	sshKey, err := ssh.NewPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	_, err = ImportAlfaPublicKey(string(ssh.MarshalAuthorizedKey(sshKey)))
+	require.ErrorIs(t, err, ErrMalformedKey)
+
+	_, err = ImportAlfaPublicKey("garbage")
+	require.ErrorIs(t, err, ErrMalformedKey)
+}
+
+func TestAlfaKeyFingerprint(t *testing.T) {
+	_, publicPem, err := GenerateAlfaKeys()
+	require.NoError(t, err)
+
+	fingerprint, err := AlfaKeyFingerprint(publicPem)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(fingerprint, "SHA256:"))
+	require.Len(t, fingerprint, len("SHA256:")+43)
+
+	// Same key with another PEM formatting
+	block, _ := pem.Decode([]byte(publicPem))
+	reformatted := "-----BEGIN PUBLIC KEY-----\r\n" + base64.StdEncoding.EncodeToString(block.Bytes) + "\r\n-----END PUBLIC KEY-----"
+	other, err := AlfaKeyFingerprint(reformatted)
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, other)
+
+	// Another key
+	_, otherPem, err := GenerateAlfaKeys()
+	require.NoError(t, err)
+	other, err = AlfaKeyFingerprint(otherPem)
+	require.NoError(t, err)
+	require.NotEqual(t, fingerprint, other)
+
+	// Known vector
+	fingerprint, err = AlfaKeyFingerprint("-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=\n-----END PUBLIC KEY-----\n")
+	require.NoError(t, err)
+	require.Equal(t, "SHA256:oekVYFTgT6yJmunydRMs3Ael28TqLCrTof/G4NJTaB8", fingerprint)
+}
+
+func TestExportAlfaJWK(t *testing.T) {
+	// ECDSA
+	privatePem, publicPem, err := GenerateAlfaKeys()
+	require.NoError(t, err)
+	privateKey, err := ParseAlfaPrivateKey(privatePem)
+	require.NoError(t, err)
+	fingerprint, err := AlfaKeyFingerprint(publicPem)
+	require.NoError(t, err)
+
+	jwk, err := ExportAlfaPublicJWK(publicPem)
+	require.NoError(t, err)
+	require.Equal(t, "EC", jwk.Kty)
+	require.Equal(t, "P-256", jwk.Crv)
+	require.Equal(t, "ES256", jwk.Alg)
+	require.Equal(t, fingerprint, jwk.Kid)
+	require.Empty(t, jwk.D)
+
+	point := append([]byte{4}, decodeBase64URL(t, jwk.X)...)
+	point = append(point, decodeBase64URL(t, jwk.Y)...)
+	ecdhKey, err := privateKey.(*ecdsa.PrivateKey).PublicKey.ECDH()
+	require.NoError(t, err)
+	require.Equal(t, ecdhKey.Bytes(), point)
+
+	privateJWK, err := ExportAlfaPrivateJWK(privatePem)
+	require.NoError(t, err)
+	require.Equal(t, jwk.X, privateJWK.X)
+	require.Equal(t, jwk.Y, privateJWK.Y)
+	ecdhPrivateKey, err := ecdh.P256().NewPrivateKey(decodeBase64URL(t, privateJWK.D))
+	require.NoError(t, err)
+	require.Equal(t, point, ecdhPrivateKey.PublicKey().Bytes())
+
+	// Ed25519
+	privatePem, publicPem, err = GenerateAlfaEd25519Keys()
+	require.NoError(t, err)
+	privateKey, err = ParseAlfaPrivateKey(privatePem)
+	require.NoError(t, err)
+
+	jwk, err = ExportAlfaPublicJWK(publicPem)
+	require.NoError(t, err)
+	require.Equal(t, "OKP", jwk.Kty)
+	require.Equal(t, "Ed25519", jwk.Crv)
+	require.Equal(t, "EdDSA", jwk.Alg)
+	require.Empty(t, jwk.Y)
+	require.Equal(t, []byte(privateKey.Public().(ed25519.PublicKey)), decodeBase64URL(t, jwk.X))
+
+	privateJWK, err = ExportAlfaPrivateJWK(privatePem)
+	require.NoError(t, err)
+	require.Equal(t, privateKey, ed25519.NewKeyFromSeed(decodeBase64URL(t, privateJWK.D)))
+
+	_, err = ExportAlfaPublicJWK("garbage")
+	require.ErrorIs(t, err, ErrMalformedKey)
+}
+
+func decodeBase64URL(t *testing.T, s string) []byte {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
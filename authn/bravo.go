@@ -6,7 +6,6 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -14,34 +13,32 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-func VerifyBravoSignature(signatureHex string, timestamp int64, now time.Time, hashedSecret []byte, request proto.Message, service string, method string) error {
+func VerifyBravoSignature(signatureHex string, timestamp int64, now time.Time, hashedSecret []byte, request proto.Message, service string, method string, opts ...VerifyOption) error {
+	o := newVerifyOptions(opts)
+
 	// Check timestamp for replays
-	ts := time.Unix(timestamp, 0)
-	if now.Add(time.Minute*5).Before(ts) || now.Add(time.Minute*-5).After(ts) {
-		return errors.New("invalid timestamp")
+	err := checkTimestamp(timestamp, now, o)
+	if err != nil {
+		return err
 	}
 
 	// Serialize timestamp and request body
-	requestBytes, err := serializeRequest(request)
+	data, err := signaturePayload(timestamp, request, service, method, o.headers)
 	if err != nil {
 		return err
 	}
-	timestampBytes := serializeTimestamp(timestamp)
-
-	data := append(timestampBytes, []byte(fmt.Sprintf("%s.%s", service, method))...)
-	data = append(data, requestBytes...)
 
 	// Decode signature from HEX
-	signature, err := hex.DecodeString(signatureHex)
+	signature, err := decodeCanonicalHex(signatureHex)
 	if err != nil {
-		return err
+		return malformedSignature(err)
 	}
 
 	// Verify timestamped request
 	if verifyHMAC(hashedSecret, data, signature) {
 		return nil
 	} else {
-		return errors.New("signature mismatch")
+		return ErrSignatureMismatch
 	}
 }
 
@@ -57,20 +54,22 @@ func GenerateBravoSecret() string {
 	return base64.StdEncoding.EncodeToString(buf)
 }
 
-func SignBravo(timestamp int64, secretBase64 string, request proto.Message, service string, method string) (string, error) {
-	// Serialize timestamp and request body
-	requestBytes, err := serializeRequest(request)
+func SignBravo(timestamp int64, secretBase64 string, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	// Hash secret with a date
+	date := GetDateOfTimestamp(timestamp)
+	hashedSecret, err := HashBravoSecretWithDate(secretBase64, date)
 	if err != nil {
 		return "", err
 	}
-	timestampBytes := serializeTimestamp(timestamp)
 
-	data := append(timestampBytes, []byte(fmt.Sprintf("%s.%s", service, method))...)
-	data = append(data, requestBytes...)
+	return SignBravoWithHashedSecret(timestamp, hashedSecret, request, service, method, opts...)
+}
 
-	// Hash secret with a date
-	date := GetDateOfTimestamp(timestamp)
-	hashedSecret, err := HashBravoSecretWithDate(secretBase64, date)
+// SignBravoWithHashedSecret is the same as SignBravo, but takes a secret already hashed with the date of timestamp
+// (see HashBravoSecretWithDate)
+func SignBravoWithHashedSecret(timestamp int64, hashedSecret []byte, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	// Serialize timestamp and request body
+	data, err := signaturePayload(timestamp, request, service, method, newSignOptions(opts).headers)
 	if err != nil {
 		return "", err
 	}
@@ -85,14 +84,56 @@ func SignBravo(timestamp int64, secretBase64 string, request proto.Message, serv
 	return hex.EncodeToString(signature), nil
 }
 
+// GetDateOfTimestamp returns the date of a timestamp which Bravo and Charlie secrets are hashed with. Dates are UTC on
+// the wire, so signers and verifiers agree regardless of their time zones (older versions returned local dates, which
+// only matched on hosts running in UTC).
 func GetDateOfTimestamp(timestamp int64) string {
-	return time.Unix(timestamp, 0).Format("2006-01-02")
+	return time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+}
+
+// ValidateBravoSecret checks that a Bravo secret is a valid non-empty Base64 string
+func ValidateBravoSecret(secretBase64 string) error {
+	secret, err := base64.StdEncoding.DecodeString(secretBase64)
+	if err != nil {
+		return malformedKey(err)
+	}
+	if len(secret) == 0 {
+		return fmt.Errorf("%w: empty secret", ErrMalformedKey)
+	}
+	return nil
+}
+
+// DeriveBravoKeys hashes a Bravo secret with dates of yesterday, today and tomorrow (UTC) relative to now. The result
+// is keyed by date and can be passed to VerifyBravoSignatureWithKeys, so requests signed around midnight are verified
+// with the key of the date they were signed at.
+func DeriveBravoKeys(secretBase64 string, now time.Time) (map[string][]byte, error) {
+	keys := make(map[string][]byte, 3)
+	for _, day := range []int{-1, 0, 1} {
+		date := now.UTC().AddDate(0, 0, day).Format("2006-01-02")
+		hashedSecret, err := HashBravoSecretWithDate(secretBase64, date)
+		if err != nil {
+			return nil, err
+		}
+		keys[date] = hashedSecret
+	}
+	return keys, nil
+}
+
+// VerifyBravoSignatureWithKeys is the same as VerifyBravoSignature, but picks a hashed secret by the date of timestamp
+// from keys derived with DeriveBravoKeys
+func VerifyBravoSignatureWithKeys(signatureHex string, timestamp int64, now time.Time, keys map[string][]byte, request proto.Message, service string, method string, opts ...VerifyOption) error {
+	hashedSecret, ok := keys[GetDateOfTimestamp(timestamp)]
+	if !ok {
+		return ErrTimestampOutOfRange
+	}
+
+	return VerifyBravoSignature(signatureHex, timestamp, now, hashedSecret, request, service, method, opts...)
 }
 
 func HashBravoSecretWithDate(secretBase64 string, date string) ([]byte, error) {
 	secret, err := base64.StdEncoding.DecodeString(secretBase64)
 	if err != nil {
-		return nil, err
+		return nil, malformedKey(err)
 	}
 
 	h := sha256.New()
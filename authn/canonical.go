@@ -0,0 +1,186 @@
+package authn
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CanonicalRequest encodes a request in the canonical form which is signed (see the package documentation for the
+// exact rules). The result is the same as proto.MarshalOptions{Deterministic: true} of the current Go protobuf
+// runtime produces, but it does not depend on any protobuf implementation or version.
+func CanonicalRequest(request proto.Message) ([]byte, error) {
+	if request == nil {
+		return nil, nil
+	}
+	return appendCanonicalMessage(nil, request.ProtoReflect())
+}
+
+func appendCanonicalMessage(b []byte, m protoreflect.Message) ([]byte, error) {
+	if !m.IsValid() {
+		return b, nil
+	}
+
+	// Populated fields (non-default for fields without presence) in ascending order of field numbers
+	var fields []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Number() < fields[j].Number()
+	})
+
+	var err error
+	for _, fd := range fields {
+		v := m.Get(fd)
+		switch {
+		case fd.IsMap():
+			b, err = appendCanonicalMap(b, fd, v.Map())
+		case fd.IsList() && fd.IsPacked():
+			b, err = appendCanonicalPackedList(b, fd, v.List())
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				b, err = appendCanonicalField(b, fd, list.Get(i))
+			}
+		default:
+			b, err = appendCanonicalField(b, fd, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Unknown fields are kept as is, after known fields
+	return append(b, m.GetUnknown()...), nil
+}
+
+func appendCanonicalField(b []byte, fd protoreflect.FieldDescriptor, v protoreflect.Value) ([]byte, error) {
+	if fd.Kind() == protoreflect.GroupKind {
+		b = protowire.AppendTag(b, fd.Number(), protowire.StartGroupType)
+		var err error
+		b, err = appendCanonicalMessage(b, v.Message())
+		if err != nil {
+			return nil, err
+		}
+		return protowire.AppendTag(b, fd.Number(), protowire.EndGroupType), nil
+	}
+
+	b = protowire.AppendTag(b, fd.Number(), wireType(fd.Kind()))
+	return appendCanonicalValue(b, fd, v)
+}
+
+func appendCanonicalPackedList(b []byte, fd protoreflect.FieldDescriptor, list protoreflect.List) ([]byte, error) {
+	var packed []byte
+	var err error
+	for i := 0; i < list.Len(); i++ {
+		packed, err = appendCanonicalValue(packed, fd, list.Get(i))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b = protowire.AppendTag(b, fd.Number(), protowire.BytesType)
+	return protowire.AppendBytes(b, packed), nil
+}
+
+// appendCanonicalMap encodes map entries as messages with key (1) and value (2), both always present, sorted by key
+func appendCanonicalMap(b []byte, fd protoreflect.FieldDescriptor, m protoreflect.Map) ([]byte, error) {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return lessMapKey(fd.MapKey().Kind(), keys[i], keys[j])
+	})
+
+	var err error
+	for _, k := range keys {
+		var entry []byte
+		entry, err = appendCanonicalField(entry, fd.MapKey(), k.Value())
+		if err != nil {
+			return nil, err
+		}
+		entry, err = appendCanonicalField(entry, fd.MapValue(), m.Get(k))
+		if err != nil {
+			return nil, err
+		}
+
+		b = protowire.AppendTag(b, fd.Number(), protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+
+	return b, nil
+}
+
+func appendCanonicalValue(b []byte, fd protoreflect.FieldDescriptor, v protoreflect.Value) ([]byte, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool())), nil
+	case protoreflect.EnumKind:
+		return protowire.AppendVarint(b, uint64(v.Enum())), nil
+	case protoreflect.Int32Kind, protoreflect.Int64Kind:
+		return protowire.AppendVarint(b, uint64(v.Int())), nil
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return protowire.AppendVarint(b, v.Uint()), nil
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(v.Int())), nil
+	case protoreflect.Fixed32Kind:
+		return protowire.AppendFixed32(b, uint32(v.Uint())), nil
+	case protoreflect.Sfixed32Kind:
+		return protowire.AppendFixed32(b, uint32(v.Int())), nil
+	case protoreflect.FloatKind:
+		return protowire.AppendFixed32(b, math.Float32bits(float32(v.Float()))), nil
+	case protoreflect.Fixed64Kind:
+		return protowire.AppendFixed64(b, v.Uint()), nil
+	case protoreflect.Sfixed64Kind:
+		return protowire.AppendFixed64(b, uint64(v.Int())), nil
+	case protoreflect.DoubleKind:
+		return protowire.AppendFixed64(b, math.Float64bits(v.Float())), nil
+	case protoreflect.StringKind:
+		return protowire.AppendString(b, v.String()), nil
+	case protoreflect.BytesKind:
+		return protowire.AppendBytes(b, v.Bytes()), nil
+	case protoreflect.MessageKind:
+		message, err := appendCanonicalMessage(nil, v.Message())
+		if err != nil {
+			return nil, err
+		}
+		return protowire.AppendBytes(b, message), nil
+	default:
+		return nil, fmt.Errorf("unsupported field kind %s of %s", fd.Kind(), fd.FullName())
+	}
+}
+
+func wireType(kind protoreflect.Kind) protowire.Type {
+	switch kind {
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return protowire.Fixed32Type
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return protowire.Fixed64Type
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind:
+		return protowire.BytesType
+	default:
+		return protowire.VarintType
+	}
+}
+
+// lessMapKey orders map keys: false before true, integers numerically, strings by UTF-8 bytes
+func lessMapKey(kind protoreflect.Kind, a protoreflect.MapKey, b protoreflect.MapKey) bool {
+	switch kind {
+	case protoreflect.BoolKind:
+		return !a.Bool() && b.Bool()
+	case protoreflect.StringKind:
+		return a.String() < b.String()
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return a.Uint() < b.Uint()
+	default:
+		return a.Int() < b.Int()
+	}
+}
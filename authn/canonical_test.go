@@ -0,0 +1,99 @@
+package authn
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCanonicalRequestMatchesDeterministicMarshal(t *testing.T) {
+	st, err := structpb.NewStruct(map[string]any{
+		"zeta":  1.5,
+		"alpha": []any{"a", true, nil, -7.0},
+		"beta":  map[string]any{"nested": "value", "another": false},
+		"":      "empty key",
+	})
+	require.NoError(t, err)
+
+	messages := []proto.Message{
+		// Empty message
+		&descriptorpb.FileDescriptorProto{},
+
+		// Scalars
+		wrapperspb.Double(-math.Pi),
+		wrapperspb.Float(math.MaxFloat32),
+		wrapperspb.Int64(math.MinInt64),
+		wrapperspb.UInt64(math.MaxUint64),
+		wrapperspb.Int32(-1),
+		wrapperspb.UInt32(math.MaxUint32),
+		wrapperspb.Bool(true),
+		wrapperspb.String("ünïcødé"),
+		wrapperspb.Bytes([]byte{0, 1, 2, 255}),
+
+		// Maps (sorted by key), oneofs, nested messages
+		st,
+
+		// proto2 optional fields with default values, enums, packed and unpacked repeated fields
+		&descriptorpb.FileDescriptorProto{
+			Name:             proto.String(""),
+			Dependency:       []string{"b.proto", "a.proto"},
+			PublicDependency: []int32{3, 1, 2},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Message"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("field"),
+					Number: proto.Int32(1),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+				}},
+			}},
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+				Location: []*descriptorpb.SourceCodeInfo_Location{{
+					Path: []int32{4, 0, 2, -1},
+					Span: []int32{10, 2, 30},
+				}},
+			},
+			Syntax: proto.String("proto3"),
+		},
+	}
+
+	for _, message := range messages {
+		expected, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		require.NoError(t, err)
+
+		actual, err := CanonicalRequest(message)
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(expected), hex.EncodeToString(actual), "%T", message)
+	}
+}
+
+func TestCanonicalRequestUnknownFields(t *testing.T) {
+	message := wrapperspb.String("value")
+
+	var unknown []byte
+	unknown = protowire.AppendTag(unknown, 100, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 42)
+	message.ProtoReflect().SetUnknown(unknown)
+
+	// Unknown fields are signed as is, after known fields
+	actual, err := CanonicalRequest(message)
+	require.NoError(t, err)
+	require.Equal(t, "0a0576616c7565a0062a", hex.EncodeToString(actual))
+}
+
+func TestCanonicalRequestNil(t *testing.T) {
+	actual, err := CanonicalRequest(nil)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+
+	actual, err = CanonicalRequest((*wrapperspb.StringValue)(nil))
+	require.NoError(t, err)
+	require.Empty(t, actual)
+}
@@ -0,0 +1,123 @@
+package authn
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Charlie API keys are symmetric like Bravo keys, but a signing key is derived from the secret for a date and a
+// service. A derived key is only valid for one service during one day, so it can be handed to a frontend of a single
+// service without exposing the secret. Signatures are Base64 encoded HMAC-SHA256.
+
+// charlieKeyDerivationLabel separates Charlie key derivation from other uses of the same secret
+const charlieKeyDerivationLabel = "evrblk-charlie"
+
+func VerifyCharlieSignature(signatureBase64 string, timestamp int64, now time.Time, hashedSecret []byte, request proto.Message, service string, method string, opts ...VerifyOption) error {
+	o := newVerifyOptions(opts)
+
+	// Check timestamp for replays
+	err := checkTimestamp(timestamp, now, o)
+	if err != nil {
+		return err
+	}
+
+	// Serialize timestamp and request body
+	data, err := signaturePayload(timestamp, request, service, method, o.headers)
+	if err != nil {
+		return err
+	}
+
+	// Decode signature from Base64
+	signature, err := decodeCanonicalBase64(base64.StdEncoding, signatureBase64)
+	if err != nil {
+		return malformedSignature(err)
+	}
+
+	// Verify timestamped request
+	if verifyHMAC(hashedSecret, data, signature) {
+		return nil
+	} else {
+		return ErrSignatureMismatch
+	}
+}
+
+func GenerateCharlieSecret() string {
+	// Generate random bytes
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		log.Fatalf("error while generating random string: %s", err)
+	}
+
+	// Return Base64 of those bytes
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func SignCharlie(timestamp int64, secretBase64 string, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	// Derive a key for the date and the service
+	date := GetDateOfTimestamp(timestamp)
+	hashedSecret, err := HashCharlieSecret(secretBase64, date, service)
+	if err != nil {
+		return "", err
+	}
+
+	return SignCharlieWithHashedSecret(timestamp, hashedSecret, request, service, method, opts...)
+}
+
+// SignCharlieWithHashedSecret is the same as SignCharlie, but takes a key already derived for the date of timestamp
+// and the service (see HashCharlieSecret)
+func SignCharlieWithHashedSecret(timestamp int64, hashedSecret []byte, request proto.Message, service string, method string, opts ...SignOption) (string, error) {
+	// Serialize timestamp and request body
+	data, err := signaturePayload(timestamp, request, service, method, newSignOptions(opts).headers)
+	if err != nil {
+		return "", err
+	}
+
+	// Sign
+	signature, err := generateHMAC(hashedSecret, data)
+	if err != nil {
+		return "", err
+	}
+
+	// Return Base64 of signature
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// ValidateCharlieSecret checks that a Charlie secret is a valid Base64 string of at least 32 bytes
+func ValidateCharlieSecret(secretBase64 string) error {
+	secret, err := base64.StdEncoding.DecodeString(secretBase64)
+	if err != nil {
+		return malformedKey(err)
+	}
+	if len(secret) < sha256.Size {
+		return fmt.Errorf("%w: secret is too short", ErrMalformedKey)
+	}
+	return nil
+}
+
+// HashCharlieSecret derives a signing key of a Charlie secret for a date and a service:
+// HMAC(HMAC(HMAC(secret, "evrblk-charlie"), date), service)
+func HashCharlieSecret(secretBase64 string, date string, service string) ([]byte, error) {
+	secret, err := base64.StdEncoding.DecodeString(secretBase64)
+	if err != nil {
+		return nil, malformedKey(err)
+	}
+
+	key := secret
+	for _, label := range []string{charlieKeyDerivationLabel, date, service} {
+		h := hmac.New(sha256.New, key)
+		_, err = h.Write([]byte(label))
+		if err != nil {
+			return nil, err
+		}
+		key = h.Sum(nil)
+	}
+	return key, nil
+}
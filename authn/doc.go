@@ -0,0 +1,133 @@
+// Package authn is the reference implementation of Everblack request signatures. Other SDKs must produce exactly the
+// same bytes, golden test vectors live in internal/test/testdata/signature_vectors.json.
+//
+// # Signature version 1
+//
+// A signed request carries these gRPC metadata headers:
+//
+//	evrblk-api-key-id:        API key ID (key_alfa_..., key_bravo_... or key_charlie_...)
+//	evrblk-timestamp:         Unix time in seconds, decimal
+//	evrblk-signature:         signature, encoded depending on the key type
+//	evrblk-signature-version: "1" (a missing header means "1")
+//
+// The signed payload is a concatenation of:
+//
+//  1. timestamp as 8 bytes big-endian two's complement integer;
+//  2. "<service>.<method>" in UTF-8, where service is a short service name ("Moab", "Grackle", ...) and method is a
+//     gRPC method name ("CreateQueue");
+//  3. canonical encoding of the request message.
+//
+// # Signature version 2
+//
+// Version 2 also signs request headers. A signed request carries the same headers as version 1 with
+// evrblk-signature-version "2", and:
+//
+//	evrblk-signed-headers: comma separated names of signed headers, lowercase, sorted and unique; it must include
+//	                       evrblk-api-key-id
+//	evrblk-deadline:       optional, deadline of the call as Unix time in milliseconds (server time), signed if sent
+//	evrblk-nonce:          optional, random string unique for every signature, signed if sent; servers with a replay
+//	                       cache require it
+//
+// The signed payload is a concatenation of:
+//
+//  1. ASCII "EVRBLK-SIGNATURE-V2" followed by a zero byte;
+//  2. timestamp as 8 bytes big-endian two's complement integer;
+//  3. "<service>.<method>" in UTF-8, prefixed with its length as 4 bytes big-endian integer;
+//  4. number of signed headers as 4 bytes big-endian integer, then for every signed header sorted by name: its name
+//     prefixed with its length, number of its values as 4 bytes big-endian integer, and every value (in order they
+//     were sent) prefixed with its length; a listed header which is not sent has zero values;
+//  5. canonical encoding of the request message.
+//
+// Lengths are 4 bytes big-endian integers, values of binary (-bin) headers are signed decoded. Keys are used the
+// same way as in version 1.
+//
+// # Streams
+//
+// Server-streaming calls are signed as unary calls, with the single request message. Opening of client- and
+// bidi-streaming calls is signed as a request with an empty body (empty canonical encoding), with either version.
+// Then every message sent by a client is signed with version 2 payload with:
+//
+//   - timestamp of the opening signature;
+//   - the same service and method;
+//   - signed headers evrblk-api-key-id, evrblk-stream-sequence (number of the message starting from 1, decimal) and
+//     evrblk-stream-previous (signature of the previous message, or of the opening for the first message);
+//   - canonical encoding of the message without its signature.
+//
+// A message signature is sent inside the message as a string field 536870911 (the largest field number), appended
+// after all other fields. Servers remove it before passing a message to a handler. Message timestamps are not checked
+// against server time, since the opening signature already was.
+//
+// # Sessions
+//
+// A long-term Alfa or Bravo API key can delegate signing to a short-lived Ed25519 session key pair. A session token
+// is "<claims>.<signature>", both parts are unpadded Base64 URL encoded. Claims are a JSON object:
+//
+//	api_key_id:  ID of the long-term API key which issued the session
+//	public_key:  public PEM of the session key pair
+//	issued_at:   Unix time in seconds
+//	expires_at:  Unix time in seconds
+//	methods:     optional list of allowed services ("Moab") and methods ("Moab.GetQueue")
+//
+// The signature is made by the long-term key over ASCII "EVRBLK-SESSION-V1" followed by a zero byte and the claims
+// exactly as encoded in the token: raw (not Base64) Alfa signature, or HMAC-SHA256 keyed with a Bravo secret hashed
+// with the date of issued_at.
+//
+// Requests are signed with the session private key as Alfa requests with evrblk-api-key-id of the long-term key, and
+// carry the token in evrblk-session-token header. Servers verify the token, check that it is not expired and allows
+// the method, then verify the request signature with the session public key.
+//
+// # Presigned tokens
+//
+// A presigned token authorizes exactly one call without a signature of the caller. It has the same format as a
+// session token, with claims:
+//
+//	api_key_id:      ID of an Alfa or Bravo API key which issued the token
+//	service, method: the authorized call
+//	request_sha256:  lowercase hex of SHA-256 of the canonical encoding of the request
+//	issued_at:       Unix time in seconds
+//	expires_at:      Unix time in seconds
+//	nonce:           random string, unique for every token
+//
+// The signature is made the same way as for session tokens, with "EVRBLK-PRESIGNED-V1" prefix instead. The token is
+// sent in evrblk-presigned-token header instead of all signature headers. Servers can accept every token only once.
+//
+// # Canonical encoding
+//
+// The canonical encoding is protobuf binary wire format with these additional rules:
+//
+//   - fields are written in ascending order of field numbers;
+//   - fields without presence (proto3 scalars, repeated fields and maps) are omitted if they have default values or
+//     are empty, fields with presence (messages, oneofs, proto2 and proto3 optional fields) are written if set, even
+//     if set to a default value;
+//   - varints use the shortest form, negative int32 and enum values are sign-extended to 10 bytes;
+//   - repeated scalar numeric fields are written packed (a single length-delimited record) if the field is packed
+//     (proto3 default), otherwise as one record per element;
+//   - map entries are written as one record per entry, sorted by key (false before true, integers numerically,
+//     strings by UTF-8 bytes), each entry is a message with key (field 1) and value (field 2), both always written;
+//   - nested messages are canonically encoded recursively;
+//   - unknown fields are appended after all known fields, as received.
+//
+// For the current Go protobuf runtime this is the same as proto.MarshalOptions{Deterministic: true}, but the
+// encoding is defined by these rules and implemented by CanonicalRequest independently of any protobuf runtime.
+//
+// # Key types
+//
+//   - Alfa: ECDSA P-256 or Ed25519 key pair. ECDSA signs SHA-256 of the payload and the signature is ASN.1 DER,
+//     Ed25519 signs the payload itself. The signature header is standard Base64 with padding.
+//   - Bravo: HMAC-SHA256 of the payload keyed with SHA-256(date || secret), where date is UTC date of the timestamp
+//     formatted as YYYY-MM-DD and secret is Base64-decoded. The signature header is lowercase hex.
+//   - Charlie: HMAC-SHA256 of the payload keyed with HMAC(HMAC(HMAC(secret, "evrblk-charlie"), date), service),
+//     where secret is Base64-decoded and date is the same as for Bravo. The signature header is standard Base64 with
+//     padding.
+//
+// Servers accept timestamps within 5 minutes of their own time by default (see WithMaxPastSkew and
+// WithMaxFutureSkew).
+package authn
+
+const (
+	// SignatureVersion1 signs timestamp, service and method, and request body
+	SignatureVersion1 = "1"
+
+	// SignatureVersion2 also signs a list of request headers
+	SignatureVersion2 = "2"
+)
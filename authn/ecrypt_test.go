@@ -61,3 +61,42 @@ func BenchmarkVerifyP256(b *testing.B) {
 		}
 	}
 }
+
+func TestEd25519(t *testing.T) {
+	privateKey, publicKey, err := GenerateEd25519KeyPair()
+	require.NoError(t, err)
+
+	data := []byte("This is a sample text for testing.")
+	signature, err := SignEd25519(data, privateKey)
+	require.NoError(t, err)
+
+	err = VerifyEd25519(data, signature, publicKey)
+	require.NoError(t, err)
+
+	err = VerifyEd25519([]byte("This is another text."), signature, publicKey)
+	require.Error(t, err)
+}
+
+func BenchmarkVerifyEd25519(b *testing.B) {
+	// Generate key pairs
+	privateKey, publicKey, _ := GenerateEd25519KeyPair()
+
+	// Create sample data
+	data := []byte("This is a sample text for testing.")
+
+	// Sign the data
+	signature, err := SignEd25519(data, privateKey)
+	if err != nil {
+		b.Fatalf("Signing error: %s", err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// Verify the signature
+		err = VerifyEd25519(data, signature, publicKey)
+		if err != nil {
+			b.Fatalf("Verification error: %s", err)
+		}
+	}
+}
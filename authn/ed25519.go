@@ -0,0 +1,44 @@
+package authn
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// GenerateEd25519KeyPair generates a new Ed25519 key pair
+func GenerateEd25519KeyPair() (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// SignEd25519 signs the given data with a private key. Unlike ECDSA, Ed25519 signatures are deterministic.
+func SignEd25519(data []byte, privateKey ed25519.PrivateKey) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid Ed25519 private key")
+	}
+
+	return ed25519.Sign(privateKey, data), nil
+}
+
+// VerifyEd25519 checks whether the signature is valid for the given data and public key
+func VerifyEd25519(data []byte, signature []byte, publicKey ed25519.PublicKey) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: invalid Ed25519 public key", ErrMalformedKey)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return ErrMalformedSignature
+	}
+
+	valid := ed25519.Verify(publicKey, data, signature)
+	if valid {
+		return nil
+	} else {
+		return ErrSignatureMismatch
+	}
+}
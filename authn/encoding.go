@@ -0,0 +1,34 @@
+package authn
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// Signatures and tokens are only accepted in their canonical encoding. Decoders are lenient (upper case hex, non-zero
+// padding bits and line breaks in Base64), which would let the same signature be sent as different strings.
+
+// decodeCanonicalBase64 decodes a Base64 string and rejects it unless it is exactly how the decoded bytes are encoded
+func decodeCanonicalBase64(encoding *base64.Encoding, s string) ([]byte, error) {
+	data, err := encoding.Strict().DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if encoding.EncodeToString(data) != s {
+		return nil, errors.New("non-canonical base64 encoding")
+	}
+	return data, nil
+}
+
+// decodeCanonicalHex decodes a lowercase hex string
+func decodeCanonicalHex(s string) ([]byte, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(data) != s {
+		return nil, errors.New("non-lowercase hex encoding")
+	}
+	return data, nil
+}
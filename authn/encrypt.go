@@ -1,8 +1,6 @@
 package authn
 
 import (
-	"errors"
-
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -43,16 +41,20 @@ func SignP256(data []byte, privateKey *ecdsa.PrivateKey) ([]byte, error) {
 func VerifyP256(data []byte, signature []byte, publicKey *ecdsa.PublicKey) error {
 	hash := sha256.Sum256(data)
 
+	// Trailing data would make signatures malleable
 	sig := ECDSASignature{}
-	_, err := asn1.Unmarshal(signature, &sig)
+	rest, err := asn1.Unmarshal(signature, &sig)
 	if err != nil {
-		return err
+		return malformedSignature(err)
+	}
+	if len(rest) > 0 || sig.R == nil || sig.S == nil {
+		return ErrMalformedSignature
 	}
 
 	valid := ecdsa.Verify(publicKey, hash[:], sig.R, sig.S)
 	if valid {
 		return nil
 	} else {
-		return errors.New("invalid signature")
+		return ErrSignatureMismatch
 	}
 }
@@ -0,0 +1,29 @@
+package authn
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Verification errors. Errors returned by Verify* functions wrap one of them or ErrTimestampOutOfRange, so callers
+// can tell them apart with errors.Is.
+var (
+	// ErrMalformedSignature is returned when a signature can't be decoded
+	ErrMalformedSignature = errors.New("malformed signature")
+
+	// ErrSignatureMismatch is returned when a well-formed signature does not match a request
+	ErrSignatureMismatch = errors.New("signature mismatch")
+
+	// ErrMalformedKey is returned when a key or a secret can't be parsed or has unsupported type
+	ErrMalformedKey = errors.New("malformed key")
+)
+
+// malformedSignature wraps a decoding error into ErrMalformedSignature
+func malformedSignature(err error) error {
+	return fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+}
+
+// malformedKey wraps a parsing error into ErrMalformedKey
+func malformedKey(err error) error {
+	return fmt.Errorf("%w: %v", ErrMalformedKey, err)
+}
@@ -0,0 +1,100 @@
+package authn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// signatureV2Prefix starts version 2 payloads, so they can never be confused with version 1 payloads
+const signatureV2Prefix = "EVRBLK-SIGNATURE-V2\x00"
+
+type signOptions struct {
+	headers map[string][]string
+}
+
+// SignOption configures signing
+type SignOption func(*signOptions)
+
+// WithSignedHeaders switches signing to signature version 2 and signs given headers (names must be lowercase). The
+// list of their names (see SignedHeaderNames) must be sent with the request, so a server can verify them.
+func WithSignedHeaders(headers map[string][]string) SignOption {
+	return func(o *signOptions) {
+		o.headers = headers
+	}
+}
+
+func newSignOptions(opts []SignOption) *signOptions {
+	o := &signOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithVerifiedHeaders switches verification to signature version 2 with given signed headers (only headers listed as
+// signed by a client, with their received values).
+func WithVerifiedHeaders(headers map[string][]string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.headers = headers
+	}
+}
+
+// SignedHeaderNames returns sorted names of headers, as they are listed in a signature version 2 payload
+func SignedHeaderNames(headers map[string][]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonicalHeaders serializes headers sorted by name: number of headers, then for every header its length-prefixed
+// name, number of values and length-prefixed values in received order
+func canonicalHeaders(headers map[string][]string) ([]byte, error) {
+	names := SignedHeaderNames(headers)
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(names)))
+	for _, name := range names {
+		if name == "" || name != strings.ToLower(name) {
+			return nil, fmt.Errorf("invalid signed header name %q", name)
+		}
+
+		values := headers[name]
+		data = appendLengthPrefixed(data, name)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(values)))
+		for _, value := range values {
+			data = appendLengthPrefixed(data, value)
+		}
+	}
+
+	return data, nil
+}
+
+func appendLengthPrefixed(data []byte, s string) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(s)))
+	return append(data, s...)
+}
+
+// ParseSignedHeaderNames parses a comma separated list of signed header names sent by a client. Names must be
+// lowercase, sorted and unique.
+func ParseSignedHeaderNames(list string) ([]string, error) {
+	if list == "" {
+		return nil, errors.New("empty list of signed headers")
+	}
+
+	names := strings.Split(list, ",")
+	for i, name := range names {
+		if name == "" || name != strings.ToLower(name) {
+			return nil, fmt.Errorf("invalid signed header name %q", name)
+		}
+		if i > 0 && names[i-1] >= name {
+			return nil, errors.New("signed headers are not sorted or not unique")
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,74 @@
+package authn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestParseSignedHeaderNames(t *testing.T) {
+	names, err := ParseSignedHeaderNames("evrblk-api-key-id,evrblk-deadline,x-tenant-id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"evrblk-api-key-id", "evrblk-deadline", "x-tenant-id"}, names)
+
+	for _, list := range []string{
+		"",
+		"evrblk-api-key-id,",
+		"x-tenant-id,evrblk-api-key-id",
+		"evrblk-api-key-id,evrblk-api-key-id",
+		"Evrblk-Api-Key-Id",
+	} {
+		_, err = ParseSignedHeaderNames(list)
+		require.Error(t, err, list)
+	}
+}
+
+func TestSignedHeaders(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+	secret := GenerateBravoSecret()
+	request := wrapperspb.String("value")
+	headers := map[string][]string{
+		"evrblk-api-key-id": {"key_bravo_test"},
+		"x-tenant-id":       {"tenant_1"},
+	}
+
+	hashedSecret, err := HashBravoSecretWithDate(secret, GetDateOfTimestamp(now.Unix()))
+	require.NoError(t, err)
+
+	signature, err := SignBravo(now.Unix(), secret, request, "Moab", "GetQueue", WithSignedHeaders(headers))
+	require.NoError(t, err)
+
+	err = VerifyBravoSignature(signature, now.Unix(), now, hashedSecret, request, "Moab", "GetQueue", WithVerifiedHeaders(headers))
+	require.NoError(t, err)
+
+	// Another header value
+	err = VerifyBravoSignature(signature, now.Unix(), now, hashedSecret, request, "Moab", "GetQueue", WithVerifiedHeaders(map[string][]string{
+		"evrblk-api-key-id": {"key_bravo_test"},
+		"x-tenant-id":       {"tenant_2"},
+	}))
+	require.Error(t, err)
+
+	// Values are not concatenated
+	err = VerifyBravoSignature(signature, now.Unix(), now, hashedSecret, request, "Moab", "GetQueue", WithVerifiedHeaders(map[string][]string{
+		"evrblk-api-key-id": {"key_bravo_test"},
+		"x-tenant-id":       {"tenant", "_1"},
+	}))
+	require.Error(t, err)
+
+	// Version 2 signature is not valid as version 1 and vice versa, even without headers
+	err = VerifyBravoSignature(signature, now.Unix(), now, hashedSecret, request, "Moab", "GetQueue")
+	require.Error(t, err)
+
+	signature, err = SignBravo(now.Unix(), secret, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = VerifyBravoSignature(signature, now.Unix(), now, hashedSecret, request, "Moab", "GetQueue", WithVerifiedHeaders(map[string][]string{}))
+	require.Error(t, err)
+
+	// Header names must be lowercase
+	_, err = SignBravo(now.Unix(), secret, request, "Moab", "GetQueue", WithSignedHeaders(map[string][]string{
+		"X-Tenant-Id": {"tenant_1"},
+	}))
+	require.Error(t, err)
+}
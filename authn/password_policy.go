@@ -0,0 +1,134 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordViolation is a reason why a password is rejected by a PasswordPolicy
+type PasswordViolation string
+
+const (
+	PasswordTooShort         PasswordViolation = "too_short"
+	PasswordTooLong          PasswordViolation = "too_long"
+	PasswordMissingLowercase PasswordViolation = "missing_lowercase"
+	PasswordMissingUppercase PasswordViolation = "missing_uppercase"
+	PasswordMissingDigit     PasswordViolation = "missing_digit"
+	PasswordMissingSymbol    PasswordViolation = "missing_symbol"
+	PasswordBreached         PasswordViolation = "breached"
+)
+
+// PasswordPolicyError lists all violations of a password policy
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		violations[i] = string(v)
+	}
+	return fmt.Sprintf("password violates policy: %s", strings.Join(violations, ", "))
+}
+
+// Has checks whether a given violation is present
+func (e *PasswordPolicyError) Has(violation PasswordViolation) bool {
+	for _, v := range e.Violations {
+		if v == violation {
+			return true
+		}
+	}
+	return false
+}
+
+// BreachedPasswords checks passwords against a list of known breached passwords (e.g. a local list or the Have I
+// Been Pwned range API)
+type BreachedPasswords interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// BreachedPasswordsFunc is an adapter to use ordinary functions as BreachedPasswords
+type BreachedPasswordsFunc func(ctx context.Context, password string) (bool, error)
+
+func (f BreachedPasswordsFunc) IsBreached(ctx context.Context, password string) (bool, error) {
+	return f(ctx, password)
+}
+
+// PasswordPolicy defines password strength requirements. Lengths are in characters (Unicode code points), zero
+// MaxLength means no limit.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// Breached is an optional check against breached passwords. It is called only if all other requirements are met.
+	Breached BreachedPasswords
+}
+
+// DefaultPasswordPolicy requires 12 to 128 characters and no particular character classes, following NIST SP 800-63B
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength: 12,
+	MaxLength: 128,
+}
+
+// Check checks a password against the policy. It returns *PasswordPolicyError with all violations, or an error of
+// the breached passwords check.
+func (p *PasswordPolicy) Check(ctx context.Context, password string) error {
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordTooShort)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, PasswordTooLong)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, PasswordMissingLowercase)
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, PasswordMissingUppercase)
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, PasswordMissingDigit)
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, PasswordMissingSymbol)
+	}
+
+	if len(violations) == 0 && p.Breached != nil {
+		breached, err := p.Breached.IsBreached(ctx, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, PasswordBreached)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
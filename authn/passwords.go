@@ -1,15 +1,218 @@
 package authn
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrPasswordMismatch is returned when a password does not match a hash
+	ErrPasswordMismatch = errors.New("password does not match")
+
+	// ErrMalformedPasswordHash is returned when a password hash is neither a PHC-format Argon2id hash nor a bcrypt hash
+	ErrMalformedPasswordHash = errors.New("malformed password hash")
+)
+
+// Argon2idParams are parameters of Argon2id password hashing
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are the second recommended option of RFC 9106: 64 MiB of memory, 3 iterations and 4 lanes
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Maximum Argon2id parameters. Hashes are read from storage, and a hash with huge parameters would make verification
+// allocate unbounded memory or take unbounded time, so such hashes are rejected as malformed.
+const (
+	maxArgon2idMemory     = 1024 * 1024 // 1 GiB
+	maxArgon2idIterations = 10
+	maxArgon2idSaltLength = 64
+	maxArgon2idKeyLength  = 64
+)
+
+// PasswordHasher hashes passwords with Argon2id and encodes hashes in PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//
+// It also verifies bcrypt hashes produced by earlier versions of HashPassword, so they can be migrated on a
+// successful login (see VerifyAndRehash).
+type PasswordHasher struct {
+	params Argon2idParams
+}
+
+// NewPasswordHasher creates a password hasher with given Argon2id parameters
+func NewPasswordHasher(params Argon2idParams) (*PasswordHasher, error) {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, fmt.Errorf("invalid Argon2id parameters: %+v", params)
+	}
+	if params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, fmt.Errorf("Argon2id salt must be at least 8 bytes and key at least 16 bytes: %+v", params)
+	}
+	if params.Memory > maxArgon2idMemory || params.Iterations > maxArgon2idIterations ||
+		params.SaltLength > maxArgon2idSaltLength || params.KeyLength > maxArgon2idKeyLength {
+		return nil, fmt.Errorf("Argon2id parameters exceed maximums (1 GiB of memory, 10 iterations, 64 bytes of salt and key): %+v", params)
+	}
+
+	return &PasswordHasher{
+		params: params,
+	}, nil
+}
+
+var defaultPasswordHasher = &PasswordHasher{params: DefaultArgon2idParams}
+
+// Hash hashes a password with a random salt
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return encodeArgon2idHash(h.params, salt, key), nil
+}
+
+// Verify checks a password against an Argon2id or a bcrypt hash. It returns ErrPasswordMismatch when the password
+// is wrong and ErrMalformedPasswordHash when the hash can't be parsed.
+func (h *PasswordHasher) Verify(password string, hash string) error {
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedPasswordHash, err)
+		}
+		return nil
+	}
+
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash checks whether a hash was produced by bcrypt or with other Argon2id parameters than the hasher's, and
+// should be replaced with a new hash next time the password is known
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	if isBcryptHash(hash) {
+		return true
+	}
+
+	params, _, _, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params
+}
+
+// VerifyAndRehash verifies a password and, if the hash needs rehashing (see NeedsRehash), returns a new hash which
+// should replace the stored one. The new hash is empty when the stored hash is up-to-date. Use it on login to migrate
+// bcrypt hashes and hashes with outdated parameters.
+func (h *PasswordHasher) VerifyAndRehash(password string, hash string) (string, error) {
+	err := h.Verify(password, hash)
+	if err != nil {
+		return "", err
+	}
+
+	if !h.NeedsRehash(hash) {
+		return "", nil
+	}
+	return h.Hash(password)
+}
+
+// HashPassword hashes a password with Argon2id and default parameters (see DefaultArgon2idParams)
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 13)
-	return string(bytes), err
+	return defaultPasswordHasher.Hash(password)
 }
 
+// CheckPasswordHash checks a password against an Argon2id or a bcrypt hash
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	return defaultPasswordHasher.Verify(password, hash) == nil
+}
+
+// NeedsRehash checks whether a hash should be replaced with a hash using default parameters (see
+// PasswordHasher.NeedsRehash)
+func NeedsRehash(hash string) bool {
+	return defaultPasswordHasher.NeedsRehash(hash)
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func encodeArgon2idHash(params Argon2idParams, salt []byte, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2idHash(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: unsupported Argon2 version %q", ErrMalformedPasswordHash, parts[2])
+	}
+
+	n, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || n != 3 || fmt.Sprintf("m=%d,t=%d,p=%d", params.Memory, params.Iterations, params.Parallelism) != parts[3] {
+		return params, nil, nil, fmt.Errorf("%w: invalid Argon2id parameters %q", ErrMalformedPasswordHash, parts[3])
+	}
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return params, nil, nil, fmt.Errorf("%w: invalid Argon2id parameters %q", ErrMalformedPasswordHash, parts[3])
+	}
+	if params.Memory > maxArgon2idMemory || params.Iterations > maxArgon2idIterations {
+		return params, nil, nil, fmt.Errorf("%w: Argon2id parameters %q exceed maximums", ErrMalformedPasswordHash, parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: %v", ErrMalformedPasswordHash, err)
+	}
+	if len(salt) > maxArgon2idSaltLength {
+		return params, nil, nil, fmt.Errorf("%w: Argon2id salt is longer than %d bytes", ErrMalformedPasswordHash, maxArgon2idSaltLength)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("%w: invalid Argon2id key", ErrMalformedPasswordHash)
+	}
+	if len(key) > maxArgon2idKeyLength {
+		return params, nil, nil, fmt.Errorf("%w: Argon2id key is longer than %d bytes", ErrMalformedPasswordHash, maxArgon2idKeyLength)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
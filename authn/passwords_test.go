@@ -0,0 +1,180 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$"))
+
+	require.True(t, CheckPasswordHash("correct horse battery staple", hash))
+	require.False(t, CheckPasswordHash("correct horse battery", hash))
+	require.False(t, NeedsRehash(hash))
+}
+
+func TestPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("password")
+	require.NoError(t, err)
+
+	require.NoError(t, hasher.Verify("password", hash))
+	require.ErrorIs(t, hasher.Verify("Password", hash), ErrPasswordMismatch)
+	require.False(t, hasher.NeedsRehash(hash))
+
+	// Salts are random
+	other, err := hasher.Hash("password")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+
+	// Parameters are taken from the hash, so a hasher with other parameters still verifies it
+	stronger, err := NewPasswordHasher(Argon2idParams{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	require.NoError(t, err)
+	require.NoError(t, stronger.Verify("password", hash))
+	require.True(t, stronger.NeedsRehash(hash))
+
+	newHash, err := stronger.VerifyAndRehash("password", hash)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(newHash, "$argon2id$v=19$m=2048,t=2,p=1$"))
+	require.False(t, stronger.NeedsRehash(newHash))
+
+	newHash, err = stronger.VerifyAndRehash("password", newHash)
+	require.NoError(t, err)
+	require.Empty(t, newHash)
+
+	// Invalid parameters
+	_, err = NewPasswordHasher(Argon2idParams{Memory: 1024, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	require.Error(t, err)
+	_, err = NewPasswordHasher(Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32})
+	require.Error(t, err)
+	_, err = NewPasswordHasher(Argon2idParams{Memory: 2 * 1024 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	require.Error(t, err)
+	_, err = NewPasswordHasher(Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 128})
+	require.Error(t, err)
+}
+
+func TestPasswordHasherBcryptMigration(t *testing.T) {
+	hasher, err := NewPasswordHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.True(t, hasher.NeedsRehash(string(bcryptHash)))
+	require.ErrorIs(t, hasher.Verify("wrong", string(bcryptHash)), ErrPasswordMismatch)
+
+	// Wrong password does not migrate the hash
+	newHash, err := hasher.VerifyAndRehash("wrong", string(bcryptHash))
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+	require.Empty(t, newHash)
+
+	newHash, err = hasher.VerifyAndRehash("password", string(bcryptHash))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(newHash, "$argon2id$"))
+	require.NoError(t, hasher.Verify("password", newHash))
+	require.False(t, hasher.NeedsRehash(newHash))
+}
+
+func TestPasswordHasherMalformedHash(t *testing.T) {
+	hasher, err := NewPasswordHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	for _, hash := range []string{
+		"",
+		"password",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1,x=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=4294967295,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=256$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$" + strings.Repeat("c2FsdHNhbHRzYWx0", 6) + "$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$" + strings.Repeat("a2V5a2V5a2V5a2V5", 6),
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$",
+		"$2a$10$short",
+	} {
+		require.ErrorIs(t, hasher.Verify("password", hash), ErrMalformedPasswordHash, hash)
+		require.True(t, hasher.NeedsRehash(hash), hash)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	require.NoError(t, policy.Check(context.Background(), "correct horse battery staple"))
+
+	err := policy.Check(context.Background(), "short")
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, []PasswordViolation{PasswordTooShort}, policyErr.Violations)
+
+	err = policy.Check(context.Background(), strings.Repeat("a", 129))
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, []PasswordViolation{PasswordTooLong}, policyErr.Violations)
+
+	// Length is in characters, not bytes
+	require.NoError(t, policy.Check(context.Background(), strings.Repeat("я", 12)))
+
+	policy = PasswordPolicy{
+		MinLength:        8,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+	require.NoError(t, policy.Check(context.Background(), "Passw0rd!"))
+
+	err = policy.Check(context.Background(), "pass")
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, []PasswordViolation{PasswordTooShort, PasswordMissingUppercase, PasswordMissingDigit, PasswordMissingSymbol}, policyErr.Violations)
+	require.True(t, policyErr.Has(PasswordMissingDigit))
+	require.False(t, policyErr.Has(PasswordMissingLowercase))
+	require.Equal(t, "password violates policy: too_short, missing_uppercase, missing_digit, missing_symbol", err.Error())
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	calls := 0
+	policy := DefaultPasswordPolicy
+	policy.Breached = BreachedPasswordsFunc(func(ctx context.Context, password string) (bool, error) {
+		calls++
+		return password == "password1234", nil
+	})
+
+	err := policy.Check(context.Background(), "password1234")
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, []PasswordViolation{PasswordBreached}, policyErr.Violations)
+
+	require.NoError(t, policy.Check(context.Background(), "correct horse battery staple"))
+
+	// Not called for passwords which are rejected anyway
+	err = policy.Check(context.Background(), "short")
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, 2, calls)
+
+	// Errors of the check are returned as is
+	lookupErr := errors.New("lookup failed")
+	policy.Breached = BreachedPasswordsFunc(func(ctx context.Context, password string) (bool, error) {
+		return false, lookupErr
+	})
+	require.ErrorIs(t, policy.Check(context.Background(), "correct horse battery staple"), lookupErr)
+}
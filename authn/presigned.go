@@ -0,0 +1,193 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// presignedTokenPrefix starts signed data of presigned tokens, so they can never be confused with request payloads or
+// session tokens
+const presignedTokenPrefix = "EVRBLK-PRESIGNED-V1\x00"
+
+var (
+	// ErrPresignedExpired is returned when a presigned token is used after its expiration
+	ErrPresignedExpired = errors.New("presigned token expired")
+
+	// ErrPresignedMismatch is returned when a presigned token is used for another call than it was issued for
+	ErrPresignedMismatch = errors.New("presigned token does not match the request")
+)
+
+// PresignedClaims authorize exactly one call: a method of a service with a given request body
+type PresignedClaims struct {
+	// ApiKeyId is ID of an Alfa or Bravo API key which issued the token. The call is made on behalf of this API key.
+	ApiKeyId string `json:"api_key_id"`
+
+	Service string `json:"service"`
+	Method  string `json:"method"`
+
+	// RequestSha256 is lowercase hex of SHA-256 of the canonical encoding of the request
+	RequestSha256 string `json:"request_sha256"`
+
+	// IssuedAt and ExpiresAt are Unix time in seconds
+	IssuedAt  int64 `json:"issued_at"`
+	ExpiresAt int64 `json:"expires_at"`
+
+	// Nonce makes every token unique, so servers can accept it only once
+	Nonce string `json:"nonce"`
+}
+
+// NewPresignedClaims creates claims for a call of a method of a service with a given request and a random nonce
+func NewPresignedClaims(apiKeyId string, request proto.Message, service string, method string, issuedAt int64, expiresAt int64) (*PresignedClaims, error) {
+	requestSha256, err := hashRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresignedClaims{
+		ApiKeyId:      apiKeyId,
+		Service:       service,
+		Method:        method,
+		RequestSha256: requestSha256,
+		IssuedAt:      issuedAt,
+		ExpiresAt:     expiresAt,
+		Nonce:         base64.RawURLEncoding.EncodeToString(nonce),
+	}, nil
+}
+
+// Matches checks that a call is exactly the one the token was issued for
+func (c *PresignedClaims) Matches(request proto.Message, service string, method string) error {
+	if c.Service != service || c.Method != method {
+		return ErrPresignedMismatch
+	}
+
+	requestSha256, err := hashRequest(request)
+	if err != nil {
+		return err
+	}
+	if requestSha256 != c.RequestSha256 {
+		return ErrPresignedMismatch
+	}
+
+	return nil
+}
+
+// CheckExpiration checks that the token has not expired at a given time
+func (c *PresignedClaims) CheckExpiration(now time.Time) error {
+	if now.Unix() >= c.ExpiresAt {
+		return ErrPresignedExpired
+	}
+	return nil
+}
+
+// PresignedToken is a parsed presigned token. Its claims can't be trusted until the token is verified with a key of
+// the API key which issued it (see VerifyAlfa and VerifyBravo).
+type PresignedToken struct {
+	Claims PresignedClaims
+
+	claims    []byte
+	signature []byte
+}
+
+// IssueAlfaPresignedToken signs presigned claims with an Alfa private key
+func IssueAlfaPresignedToken(claims *PresignedClaims, privateKey crypto.Signer) (string, error) {
+	data, err := presignedTokenClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signAlfaData(tokenPayload(presignedTokenPrefix, data), privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(data, signature), nil
+}
+
+// IssueBravoPresignedToken signs presigned claims with a Bravo secret hashed with the date of claims.IssuedAt (see
+// HashBravoSecretWithDate)
+func IssueBravoPresignedToken(claims *PresignedClaims, hashedSecret []byte) (string, error) {
+	data, err := presignedTokenClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := generateHMAC(hashedSecret, tokenPayload(presignedTokenPrefix, data))
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(data, signature), nil
+}
+
+// ParsePresignedToken parses a presigned token without verifying it
+func ParsePresignedToken(token string) (*PresignedToken, error) {
+	claims, signature, err := decodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &PresignedToken{
+		claims:    claims,
+		signature: signature,
+	}
+	err = json.Unmarshal(claims, &t.Claims)
+	if err != nil {
+		return nil, fmt.Errorf("malformed presigned token claims: %w", err)
+	}
+	if t.Claims.ApiKeyId == "" || t.Claims.Service == "" || t.Claims.Method == "" || t.Claims.RequestSha256 == "" {
+		return nil, errors.New("incomplete presigned token claims")
+	}
+
+	return t, nil
+}
+
+// VerifyAlfa verifies the token with a public PEM of the Alfa API key which issued it
+func (t *PresignedToken) VerifyAlfa(publicPem string) error {
+	return verifyAlfaData(tokenPayload(presignedTokenPrefix, t.claims), t.signature, publicPem)
+}
+
+// VerifyBravo verifies the token with a secret of the Bravo API key which issued it, hashed with the date of
+// Claims.IssuedAt
+func (t *PresignedToken) VerifyBravo(hashedSecret []byte) error {
+	if !verifyHMAC(hashedSecret, tokenPayload(presignedTokenPrefix, t.claims), t.signature) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func presignedTokenClaims(claims *PresignedClaims) ([]byte, error) {
+	if claims.ExpiresAt <= claims.IssuedAt {
+		return nil, errors.New("presigned token expires before it is issued")
+	}
+	if claims.Nonce == "" {
+		return nil, errors.New("presigned token must have a nonce")
+	}
+
+	return json.Marshal(claims)
+}
+
+// hashRequest returns lowercase hex of SHA-256 of the canonical encoding of a request
+func hashRequest(request proto.Message) (string, error) {
+	data, err := CanonicalRequest(request)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,110 @@
+package authn
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestAlfaPresignedToken(t *testing.T) {
+	privatePem, publicPem, err := GenerateAlfaKeys()
+	require.NoError(t, err)
+	privateKey, err := ParseAlfaPrivateKey(privatePem)
+	require.NoError(t, err)
+
+	request := wrapperspb.String("my_queue")
+	claims, err := NewPresignedClaims("key_alfa_test", request, "Moab", "GetQueue", 1733240571, 1733240871)
+	require.NoError(t, err)
+
+	token, err := IssueAlfaPresignedToken(claims, privateKey)
+	require.NoError(t, err)
+
+	presigned, err := ParsePresignedToken(token)
+	require.NoError(t, err)
+	require.Equal(t, *claims, presigned.Claims)
+	require.NoError(t, presigned.VerifyAlfa(publicPem))
+
+	// Another key
+	_, otherPublicPem, err := GenerateAlfaKeys()
+	require.NoError(t, err)
+	require.Error(t, presigned.VerifyAlfa(otherPublicPem))
+
+	// Presigned token is not a session token, even though formats are the same
+	_, err = ParseSessionToken(token)
+	require.Error(t, err)
+}
+
+func TestBravoPresignedToken(t *testing.T) {
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), "2024-12-03")
+	require.NoError(t, err)
+
+	claims, err := NewPresignedClaims("key_bravo_test", wrapperspb.String("my_queue"), "Moab", "GetQueue", 1733240571, 1733240871)
+	require.NoError(t, err)
+
+	token, err := IssueBravoPresignedToken(claims, hashedSecret)
+	require.NoError(t, err)
+
+	presigned, err := ParsePresignedToken(token)
+	require.NoError(t, err)
+	require.NoError(t, presigned.VerifyBravo(hashedSecret))
+
+	// Tokens for the same call are unique
+	other, err := NewPresignedClaims("key_bravo_test", wrapperspb.String("my_queue"), "Moab", "GetQueue", 1733240571, 1733240871)
+	require.NoError(t, err)
+	require.NotEqual(t, claims.Nonce, other.Nonce)
+}
+
+func TestPresignedClaims(t *testing.T) {
+	request := wrapperspb.String("my_queue")
+	claims, err := NewPresignedClaims("key_alfa_test", request, "Moab", "GetQueue", 1733240571, 1733240871)
+	require.NoError(t, err)
+
+	require.NoError(t, claims.Matches(wrapperspb.String("my_queue"), "Moab", "GetQueue"))
+	require.ErrorIs(t, claims.Matches(wrapperspb.String("other_queue"), "Moab", "GetQueue"), ErrPresignedMismatch)
+	require.ErrorIs(t, claims.Matches(request, "Moab", "DeleteQueue"), ErrPresignedMismatch)
+	require.ErrorIs(t, claims.Matches(request, "Grackle", "GetQueue"), ErrPresignedMismatch)
+
+	require.NoError(t, claims.CheckExpiration(time.Unix(1733240870, 0)))
+	require.ErrorIs(t, claims.CheckExpiration(time.Unix(1733240871, 0)), ErrPresignedExpired)
+
+	// Expires before issued
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), "2024-12-03")
+	require.NoError(t, err)
+	claims.ExpiresAt = claims.IssuedAt
+	_, err = IssueBravoPresignedToken(claims, hashedSecret)
+	require.Error(t, err)
+}
+
+func TestParsePresignedTokenNonCanonical(t *testing.T) {
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), "2024-12-03")
+	require.NoError(t, err)
+	claims, err := NewPresignedClaims("key_bravo_test", wrapperspb.String("my_queue"), "Moab", "GetQueue", 1733240571, 1733240871)
+	require.NoError(t, err)
+	token, err := IssueBravoPresignedToken(claims, hashedSecret)
+	require.NoError(t, err)
+
+	// Flipped padding bits decode to the same bytes with a lenient decoder
+	parts := strings.Split(token, ".")
+	for i, part := range parts {
+		if len(part)%4 == 0 {
+			continue
+		}
+		last := strings.IndexByte(base64URLAlphabet, part[len(part)-1])
+		parts[i] = part[:len(part)-1] + string(base64URLAlphabet[last^1])
+	}
+	tampered := strings.Join(parts, ".")
+	require.NotEqual(t, token, tampered)
+
+	_, err = ParsePresignedToken(tampered)
+	require.Error(t, err)
+
+	// Padded encoding
+	claimsBase64, signatureBase64, _ := strings.Cut(token, ".")
+	_, err = ParsePresignedToken(claimsBase64 + "." + signatureBase64 + "=")
+	require.Error(t, err)
+}
+
+const base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
@@ -0,0 +1,149 @@
+package authn
+
+import (
+	"container/heap"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrReplayedSignature is returned by ReplayCache when the same signed request has already been seen within its
+	// validity window
+	ErrReplayedSignature = errors.New("replayed signature")
+
+	// ErrReplayCacheFull is returned by MemoryReplayCache when it cannot store more requests. A request is rejected
+	// in this case, since it cannot be proven it was not replayed.
+	ErrReplayCacheFull = errors.New("replay cache is full")
+)
+
+// ReplayCache remembers verified requests to reject the same signed request sent again. Requests are identified by an
+// API key, a timestamp and a digest of signed data (see RequestDigest) rather than by signatures as sent, since a
+// signature can be re-encoded or, for ECDSA, malleated into a different string which still verifies. Shared backends
+// (e.g. Redis with SET NX and TTL) can implement this interface to protect a fleet of servers.
+type ReplayCache interface {
+	// CheckAndStore atomically checks whether a request was already seen and stores it until expiresAt. Now is the
+	// current time of a verifier, which entries must expire against rather than a clock of the cache, so a request is
+	// remembered as long as the verifier accepts its timestamp. Returns ErrReplayedSignature if the request was
+	// already seen.
+	CheckAndStore(ctx context.Context, apiKeyId string, timestamp int64, digest string, now time.Time, expiresAt time.Time) error
+}
+
+// RequestDigest returns lowercase hex of SHA-256 of data signed for a request, which identifies the request in
+// ReplayCache. Options must be the same as used for verification.
+func RequestDigest(timestamp int64, request proto.Message, service string, method string, opts ...VerifyOption) (string, error) {
+	data, err := signaturePayload(timestamp, request, service, method, newVerifyOptions(opts).headers)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// ReplayExpiration returns a time until which a request with a given timestamp must be remembered by ReplayCache,
+// i.e. the end of the time window in which its signature is valid. Options must be the same as used for
+// verification.
+func ReplayExpiration(timestamp int64, opts ...VerifyOption) time.Time {
+	return time.Unix(timestamp, 0).Add(newVerifyOptions(opts).maxPastSkew)
+}
+
+// MemoryReplayCache is an in-memory ReplayCache bounded by the number of stored requests. Expired requests are evicted
+// on every call.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	maxSize int
+	entries map[[sha256.Size]byte]time.Time
+	queue   replayQueue
+}
+
+var _ ReplayCache = &MemoryReplayCache{}
+
+// NewMemoryReplayCache creates a new in-memory replay cache which stores up to maxSize requests. MaxSize must be
+// positive.
+func NewMemoryReplayCache(maxSize int) (*MemoryReplayCache, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("replay cache size must be positive: %d", maxSize)
+	}
+
+	return &MemoryReplayCache{
+		maxSize: maxSize,
+		entries: make(map[[sha256.Size]byte]time.Time),
+	}, nil
+}
+
+func (c *MemoryReplayCache) CheckAndStore(ctx context.Context, apiKeyId string, timestamp int64, digest string, now time.Time, expiresAt time.Time) error {
+	key := replayKey(apiKeyId, timestamp, digest)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(now)
+
+	if _, ok := c.entries[key]; ok {
+		return ErrReplayedSignature
+	}
+	if len(c.entries) >= c.maxSize {
+		return ErrReplayCacheFull
+	}
+
+	c.entries[key] = expiresAt
+	heap.Push(&c.queue, replayEntry{key: key, expiresAt: expiresAt})
+
+	return nil
+}
+
+// Len returns the number of currently stored requests
+func (c *MemoryReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func (c *MemoryReplayCache) evictExpired(now time.Time) {
+	for len(c.queue) > 0 && !c.queue[0].expiresAt.After(now) {
+		entry := heap.Pop(&c.queue).(replayEntry)
+		delete(c.entries, entry.key)
+	}
+}
+
+// replayKey hashes a (key ID, timestamp, digest) tuple into a fixed size key to bound memory usage
+func replayKey(apiKeyId string, timestamp int64, digest string) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(apiKeyId))
+	h.Write([]byte{0})
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(timestamp)))
+	h.Write([]byte(digest))
+
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+type replayEntry struct {
+	key       [sha256.Size]byte
+	expiresAt time.Time
+}
+
+// replayQueue is a min-heap of entries ordered by expiration time
+type replayQueue []replayEntry
+
+func (q replayQueue) Len() int           { return len(q) }
+func (q replayQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q replayQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *replayQueue) Push(x any)        { *q = append(*q, x.(replayEntry)) }
+func (q *replayQueue) Pop() any {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	*q = old[:n-1]
+	return entry
+}
@@ -0,0 +1,48 @@
+package authn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	// Time of a verifier, long past by the system clock
+	now := time.Unix(1733240571, 0)
+	cache, err := NewMemoryReplayCache(2)
+	require.NoError(t, err)
+
+	timestamp := now.Unix()
+	expiresAt := ReplayExpiration(timestamp)
+
+	// First time a signature is seen
+	err = cache.CheckAndStore(context.Background(), "key_alfa_1", timestamp, "signature1", now, expiresAt)
+	require.NoError(t, err)
+
+	// Same signature is a replay
+	err = cache.CheckAndStore(context.Background(), "key_alfa_1", timestamp, "signature1", now, expiresAt)
+	require.ErrorIs(t, err, ErrReplayedSignature)
+
+	// Same signature of another key is not a replay
+	err = cache.CheckAndStore(context.Background(), "key_alfa_2", timestamp, "signature1", now, expiresAt)
+	require.NoError(t, err)
+
+	// Cache is full
+	err = cache.CheckAndStore(context.Background(), "key_alfa_1", timestamp, "signature2", now, expiresAt)
+	require.ErrorIs(t, err, ErrReplayCacheFull)
+
+	// After expiration all signatures are evicted
+	now = expiresAt
+	err = cache.CheckAndStore(context.Background(), "key_alfa_1", timestamp+600, "signature1", now, ReplayExpiration(timestamp+600))
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Len())
+}
+
+func TestNewMemoryReplayCache(t *testing.T) {
+	_, err := NewMemoryReplayCache(0)
+	require.Error(t, err)
+	_, err = NewMemoryReplayCache(-1)
+	require.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sessionTokenPrefix starts signed data of session tokens, so they can never be confused with request payloads
+const sessionTokenPrefix = "EVRBLK-SESSION-V1\x00"
+
+// ErrSessionExpired is returned when a session token is used outside of its validity period
+var ErrSessionExpired = errors.New("session expired")
+
+// SessionClaims describe a session delegated by a long-term API key to a short-lived session key pair
+type SessionClaims struct {
+	// ApiKeyId is ID of a long-term Alfa or Bravo API key which issued the session. Requests signed with the session
+	// key are made on behalf of this API key.
+	ApiKeyId string `json:"api_key_id"`
+
+	// PublicKey is a public PEM of an Ed25519 session key pair, which requests are signed with
+	PublicKey string `json:"public_key"`
+
+	// IssuedAt and ExpiresAt are Unix time in seconds
+	IssuedAt  int64 `json:"issued_at"`
+	ExpiresAt int64 `json:"expires_at"`
+
+	// Methods optionally restrict the session to given services ("Moab") or methods ("Moab.GetQueue"). All methods
+	// are allowed if empty.
+	Methods []string `json:"methods,omitempty"`
+}
+
+// Allows checks if a method of a service can be called within the session
+func (c *SessionClaims) Allows(service string, method string) bool {
+	if len(c.Methods) == 0 {
+		return true
+	}
+
+	for _, m := range c.Methods {
+		if m == service || m == service+"."+method {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExpiration checks that the session has not expired at a given time. IssuedAt is not checked, so sessions
+// issued by hosts with clocks slightly ahead are usable right away.
+func (c *SessionClaims) CheckExpiration(now time.Time) error {
+	if now.Unix() >= c.ExpiresAt {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// SessionToken is a parsed session token. Its claims can't be trusted until the token is verified with a key of the
+// API key which issued it (see VerifyAlfa and VerifyBravo).
+type SessionToken struct {
+	Claims SessionClaims
+
+	claims    []byte
+	signature []byte
+}
+
+// IssueAlfaSessionToken signs session claims with an Alfa private key
+func IssueAlfaSessionToken(claims *SessionClaims, privateKey crypto.Signer) (string, error) {
+	data, err := sessionTokenClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signAlfaData(tokenPayload(sessionTokenPrefix, data), privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(data, signature), nil
+}
+
+// IssueBravoSessionToken signs session claims with a Bravo secret hashed with the date of claims.IssuedAt (see
+// HashBravoSecretWithDate)
+func IssueBravoSessionToken(claims *SessionClaims, hashedSecret []byte) (string, error) {
+	data, err := sessionTokenClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := generateHMAC(hashedSecret, tokenPayload(sessionTokenPrefix, data))
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(data, signature), nil
+}
+
+// ParseSessionToken parses a session token without verifying it
+func ParseSessionToken(token string) (*SessionToken, error) {
+	claims, signature, err := decodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &SessionToken{
+		claims:    claims,
+		signature: signature,
+	}
+	err = json.Unmarshal(claims, &t.Claims)
+	if err != nil {
+		return nil, fmt.Errorf("malformed session token claims: %w", err)
+	}
+	if t.Claims.ApiKeyId == "" || t.Claims.PublicKey == "" {
+		return nil, errors.New("incomplete session token claims")
+	}
+
+	return t, nil
+}
+
+// VerifyAlfa verifies the token with a public PEM of the Alfa API key which issued it
+func (t *SessionToken) VerifyAlfa(publicPem string) error {
+	return verifyAlfaData(tokenPayload(sessionTokenPrefix, t.claims), t.signature, publicPem)
+}
+
+// VerifyBravo verifies the token with a secret of the Bravo API key which issued it, hashed with the date of
+// Claims.IssuedAt
+func (t *SessionToken) VerifyBravo(hashedSecret []byte) error {
+	if !verifyHMAC(hashedSecret, tokenPayload(sessionTokenPrefix, t.claims), t.signature) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func sessionTokenClaims(claims *SessionClaims) ([]byte, error) {
+	if claims.ExpiresAt <= claims.IssuedAt {
+		return nil, errors.New("session expires before it is issued")
+	}
+
+	// Session key must be a valid Ed25519 public key
+	publicKey, err := ParseAlfaPublicKey(claims.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session public key: %w", err)
+	}
+	if _, ok := publicKey.(ed25519.PublicKey); !ok {
+		return nil, errors.New("session public key must be Ed25519")
+	}
+
+	return json.Marshal(claims)
+}
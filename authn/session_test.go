@@ -0,0 +1,120 @@
+package authn
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSessionClaims(t *testing.T, apiKeyId string) *SessionClaims {
+	_, publicPem, err := GenerateAlfaEd25519Keys()
+	require.NoError(t, err)
+
+	return &SessionClaims{
+		ApiKeyId:  apiKeyId,
+		PublicKey: publicPem,
+		IssuedAt:  1733240571,
+		ExpiresAt: 1733244171,
+		Methods:   []string{"Moab.GetQueue", "Grackle"},
+	}
+}
+
+func TestAlfaSessionToken(t *testing.T) {
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(t, err)
+		privateKey, err := ParseAlfaPrivateKey(privatePem)
+		require.NoError(t, err)
+
+		claims := newTestSessionClaims(t, "key_alfa_test")
+		token, err := IssueAlfaSessionToken(claims, privateKey)
+		require.NoError(t, err)
+
+		session, err := ParseSessionToken(token)
+		require.NoError(t, err)
+		require.Equal(t, *claims, session.Claims)
+		require.NoError(t, session.VerifyAlfa(publicPem))
+
+		// Another key
+		_, otherPublicPem, err := generate()
+		require.NoError(t, err)
+		require.Error(t, session.VerifyAlfa(otherPublicPem))
+	}
+}
+
+func TestBravoSessionToken(t *testing.T) {
+	secret := GenerateBravoSecret()
+	claims := newTestSessionClaims(t, "key_bravo_test")
+	hashedSecret, err := HashBravoSecretWithDate(secret, GetDateOfTimestamp(claims.IssuedAt))
+	require.NoError(t, err)
+
+	token, err := IssueBravoSessionToken(claims, hashedSecret)
+	require.NoError(t, err)
+
+	session, err := ParseSessionToken(token)
+	require.NoError(t, err)
+	require.NoError(t, session.VerifyBravo(hashedSecret))
+
+	// Secret hashed with another date
+	otherHashedSecret, err := HashBravoSecretWithDate(secret, GetDateOfTimestamp(claims.IssuedAt+86400))
+	require.NoError(t, err)
+	require.Error(t, session.VerifyBravo(otherHashedSecret))
+
+	// Tampered claims
+	claimsBase64, signature, _ := strings.Cut(token, ".")
+	data, err := base64.RawURLEncoding.DecodeString(claimsBase64)
+	require.NoError(t, err)
+	tampered := strings.Replace(string(data), `"Grackle"`, `"Moab"`, 1)
+	session, err = ParseSessionToken(base64.RawURLEncoding.EncodeToString([]byte(tampered)) + "." + signature)
+	require.NoError(t, err)
+	require.Error(t, session.VerifyBravo(hashedSecret))
+}
+
+func TestIssueSessionTokenInvalidClaims(t *testing.T) {
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), "2024-12-03")
+	require.NoError(t, err)
+
+	// Expires before issued
+	claims := newTestSessionClaims(t, "key_bravo_test")
+	claims.ExpiresAt = claims.IssuedAt
+	_, err = IssueBravoSessionToken(claims, hashedSecret)
+	require.Error(t, err)
+
+	// Session key is not Ed25519
+	claims = newTestSessionClaims(t, "key_bravo_test")
+	_, claims.PublicKey, err = GenerateAlfaKeys()
+	require.NoError(t, err)
+	_, err = IssueBravoSessionToken(claims, hashedSecret)
+	require.Error(t, err)
+}
+
+func TestParseSessionTokenMalformed(t *testing.T) {
+	for _, token := range []string{
+		"",
+		"abc",
+		"!!!.abc",
+		base64.RawURLEncoding.EncodeToString([]byte("{")) + ".abc",
+		base64.RawURLEncoding.EncodeToString([]byte(`{"api_key_id":"key_alfa_test"}`)) + ".abc",
+	} {
+		_, err := ParseSessionToken(token)
+		require.Error(t, err, token)
+	}
+}
+
+func TestSessionClaims(t *testing.T) {
+	claims := newTestSessionClaims(t, "key_alfa_test")
+
+	require.True(t, claims.Allows("Moab", "GetQueue"))
+	require.False(t, claims.Allows("Moab", "DeleteQueue"))
+	require.True(t, claims.Allows("Grackle", "AcquireLock"))
+
+	claims.Methods = nil
+	require.True(t, claims.Allows("Moab", "DeleteQueue"))
+
+	require.NoError(t, claims.CheckExpiration(time.Unix(claims.IssuedAt, 0)))
+	require.NoError(t, claims.CheckExpiration(time.Unix(claims.ExpiresAt-1, 0)))
+	require.ErrorIs(t, claims.CheckExpiration(time.Unix(claims.ExpiresAt, 0)), ErrSessionExpired)
+}
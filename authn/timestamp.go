@@ -0,0 +1,56 @@
+package authn
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimestampOutOfRange is returned when a request timestamp is too far from server time
+var ErrTimestampOutOfRange = errors.New("timestamp out of range")
+
+// defaultTimestampSkew is the default maximum allowed difference between a request timestamp and server time
+const defaultTimestampSkew = time.Minute * 5
+
+type verifyOptions struct {
+	maxPastSkew   time.Duration
+	maxFutureSkew time.Duration
+	headers       map[string][]string
+}
+
+// VerifyOption configures signature verification
+type VerifyOption func(*verifyOptions)
+
+// WithMaxPastSkew sets how far in the past a request timestamp can be relative to server time (5 minutes by default)
+func WithMaxPastSkew(d time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.maxPastSkew = d
+	}
+}
+
+// WithMaxFutureSkew sets how far in the future a request timestamp can be relative to server time (5 minutes by
+// default)
+func WithMaxFutureSkew(d time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.maxFutureSkew = d
+	}
+}
+
+func newVerifyOptions(opts []VerifyOption) *verifyOptions {
+	o := &verifyOptions{
+		maxPastSkew:   defaultTimestampSkew,
+		maxFutureSkew: defaultTimestampSkew,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// checkTimestamp checks that a request timestamp is within allowed window around server time
+func checkTimestamp(timestamp int64, now time.Time, o *verifyOptions) error {
+	ts := time.Unix(timestamp, 0)
+	if now.Add(o.maxFutureSkew).Before(ts) || now.Add(-o.maxPastSkew).After(ts) {
+		return ErrTimestampOutOfRange
+	}
+	return nil
+}
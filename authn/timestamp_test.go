@@ -0,0 +1,26 @@
+package authn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+	timestamp := now.Unix()
+
+	// Default window is 5 minutes both ways
+	require.NoError(t, checkTimestamp(timestamp, now.Add(time.Minute*5), newVerifyOptions(nil)))
+	require.NoError(t, checkTimestamp(timestamp, now.Add(time.Minute*-5), newVerifyOptions(nil)))
+	require.Error(t, checkTimestamp(timestamp, now.Add(time.Minute*6), newVerifyOptions(nil)))
+	require.Error(t, checkTimestamp(timestamp, now.Add(time.Minute*-6), newVerifyOptions(nil)))
+
+	// Asymmetric window: 1 minute in the past, 10 minutes in the future
+	opts := newVerifyOptions([]VerifyOption{WithMaxPastSkew(time.Minute), WithMaxFutureSkew(time.Minute * 10)})
+	require.NoError(t, checkTimestamp(timestamp, now.Add(time.Minute), opts))
+	require.Error(t, checkTimestamp(timestamp, now.Add(time.Minute*2), opts))
+	require.NoError(t, checkTimestamp(timestamp, now.Add(time.Minute*-10), opts))
+	require.Error(t, checkTimestamp(timestamp, now.Add(time.Minute*-11), opts))
+}
@@ -0,0 +1,38 @@
+package authn
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Session and presigned tokens are "<claims>.<signature>", both parts unpadded Base64 URL encoded. Signed data is a
+// prefix of the token type followed by claims exactly as encoded, so tokens of one type can't be used as another.
+// Both parts are only accepted in their canonical encoding, so a token can't be sent as a different string.
+
+func encodeToken(claims []byte, signature []byte) string {
+	return base64.RawURLEncoding.EncodeToString(claims) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func decodeToken(token string) (claims []byte, signature []byte, err error) {
+	claimsBase64, signatureBase64, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, nil, errors.New("malformed token")
+	}
+
+	claims, err = decodeCanonicalBase64(base64.RawURLEncoding, claimsBase64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	signature, err = decodeCanonicalBase64(base64.RawURLEncoding, signatureBase64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	return claims, signature, nil
+}
+
+func tokenPayload(prefix string, claims []byte) []byte {
+	return append([]byte(prefix), claims...)
+}
@@ -0,0 +1,195 @@
+package authn
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const verifyTestTimestamp = int64(1733240571)
+
+// requireVerificationError checks that a verification error is one of the documented sentinel errors
+func requireVerificationError(t *testing.T, err error) {
+	if err == nil {
+		return
+	}
+	for _, sentinel := range []error{ErrTimestampOutOfRange, ErrMalformedSignature, ErrSignatureMismatch, ErrMalformedKey} {
+		if errors.Is(err, sentinel) {
+			return
+		}
+	}
+	t.Fatalf("unexpected verification error: %v", err)
+}
+
+func TestVerificationErrors(t *testing.T) {
+	request := wrapperspb.String("my_queue")
+	now := time.Unix(verifyTestTimestamp, 0)
+
+	// Alfa
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(t, err)
+		signature, err := SignAlfa(verifyTestTimestamp, privatePem, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		err = VerifyAlfaSignature(signature, verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		err = VerifyAlfaSignature(signature, verifyTestTimestamp, now.Add(time.Hour), publicPem, request, "Moab", "GetQueue")
+		require.ErrorIs(t, err, ErrTimestampOutOfRange)
+
+		err = VerifyAlfaSignature(signature, verifyTestTimestamp, now, publicPem, wrapperspb.String("other"), "Moab", "GetQueue")
+		require.ErrorIs(t, err, ErrSignatureMismatch)
+
+		err = VerifyAlfaSignature("not base64!", verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+		require.ErrorIs(t, err, ErrMalformedSignature)
+
+		err = VerifyAlfaSignature(base64.StdEncoding.EncodeToString([]byte("short")), verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+		require.ErrorIs(t, err, ErrMalformedSignature)
+
+		// Valid ASN.1 signature followed by garbage
+		if decoded, _ := base64.StdEncoding.DecodeString(signature); decoded[0] == 0x30 {
+			err = VerifyAlfaSignature(base64.StdEncoding.EncodeToString(append(decoded, 0)), verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+			require.ErrorIs(t, err, ErrMalformedSignature)
+		}
+
+		for _, malformedPem := range []string{"", "garbage", privatePem, "-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n"} {
+			err = VerifyAlfaSignature(signature, verifyTestTimestamp, now, malformedPem, request, "Moab", "GetQueue")
+			require.ErrorIs(t, err, ErrMalformedKey)
+		}
+	}
+
+	// Bravo
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), GetDateOfTimestamp(verifyTestTimestamp))
+	require.NoError(t, err)
+	signature, err := SignBravoWithHashedSecret(verifyTestTimestamp, hashedSecret, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	err = VerifyBravoSignature(signature, verifyTestTimestamp, now.Add(-time.Hour), hashedSecret, request, "Moab", "GetQueue")
+	require.ErrorIs(t, err, ErrTimestampOutOfRange)
+	err = VerifyBravoSignature(signature, verifyTestTimestamp, now, hashedSecret, request, "Moab", "DeleteQueue")
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+	err = VerifyBravoSignature("xyz", verifyTestTimestamp, now, hashedSecret, request, "Moab", "GetQueue")
+	require.ErrorIs(t, err, ErrMalformedSignature)
+
+	_, err = HashBravoSecretWithDate("not base64!", "2024-12-03")
+	require.ErrorIs(t, err, ErrMalformedKey)
+
+	// Charlie
+	hashedSecret, err = HashCharlieSecret(GenerateCharlieSecret(), GetDateOfTimestamp(verifyTestTimestamp), "Moab")
+	require.NoError(t, err)
+	signature, err = SignCharlieWithHashedSecret(verifyTestTimestamp, hashedSecret, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	err = VerifyCharlieSignature(signature, verifyTestTimestamp, now, hashedSecret, request, "Moab", "DeleteQueue")
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+	err = VerifyCharlieSignature("not base64!", verifyTestTimestamp, now, hashedSecret, request, "Moab", "GetQueue")
+	require.ErrorIs(t, err, ErrMalformedSignature)
+}
+
+// flipPaddingBits changes unused low bits of the last character of padded Base64, so the string decodes to the same
+// bytes. Returns false if there are no unused bits.
+func flipPaddingBits(s string) (string, bool) {
+	data := strings.TrimRight(s, "=")
+	if len(data) == len(s) {
+		return "", false
+	}
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	last := strings.IndexByte(alphabet, data[len(data)-1])
+	return data[:len(data)-1] + string(alphabet[last^1]) + s[len(data):], true
+}
+
+func TestNonCanonicalSignatures(t *testing.T) {
+	request := wrapperspb.String("my_queue")
+	now := time.Unix(verifyTestTimestamp, 0)
+
+	// Alfa
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(t, err)
+		signature, err := SignAlfa(verifyTestTimestamp, privatePem, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		if flipped, ok := flipPaddingBits(signature); ok {
+			err = VerifyAlfaSignature(flipped, verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+			require.ErrorIs(t, err, ErrMalformedSignature)
+		}
+		err = VerifyAlfaSignature(signature[:8]+"\n"+signature[8:], verifyTestTimestamp, now, publicPem, request, "Moab", "GetQueue")
+		require.ErrorIs(t, err, ErrMalformedSignature)
+	}
+
+	// Bravo
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), GetDateOfTimestamp(verifyTestTimestamp))
+	require.NoError(t, err)
+	signature, err := SignBravoWithHashedSecret(verifyTestTimestamp, hashedSecret, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	err = VerifyBravoSignature(strings.ToUpper(signature), verifyTestTimestamp, now, hashedSecret, request, "Moab", "GetQueue")
+	require.ErrorIs(t, err, ErrMalformedSignature)
+
+	// Charlie
+	hashedSecret, err = HashCharlieSecret(GenerateCharlieSecret(), GetDateOfTimestamp(verifyTestTimestamp), "Moab")
+	require.NoError(t, err)
+	signature, err = SignCharlieWithHashedSecret(verifyTestTimestamp, hashedSecret, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	flipped, ok := flipPaddingBits(signature)
+	require.True(t, ok)
+	err = VerifyCharlieSignature(flipped, verifyTestTimestamp, now, hashedSecret, request, "Moab", "GetQueue")
+	require.ErrorIs(t, err, ErrMalformedSignature)
+}
+
+func FuzzVerifyAlfaSignature(f *testing.F) {
+	for _, generate := range []func() (string, string, error){GenerateAlfaKeys, GenerateAlfaEd25519Keys} {
+		privatePem, publicPem, err := generate()
+		require.NoError(f, err)
+		signature, err := SignAlfa(verifyTestTimestamp, privatePem, wrapperspb.Bytes([]byte("body")), "Moab", "GetQueue")
+		require.NoError(f, err)
+
+		f.Add(signature, verifyTestTimestamp, publicPem, []byte("body"), "Moab", "GetQueue")
+		f.Add("", int64(0), publicPem, []byte{}, "", "")
+	}
+	f.Add("AAAA", int64(-1), "-----BEGIN PUBLIC KEY-----\nMAA=\n-----END PUBLIC KEY-----\n", []byte{0xff}, "Moab", "GetQueue")
+
+	f.Fuzz(func(t *testing.T, signature string, timestamp int64, publicPem string, body []byte, service string, method string) {
+		// Server time equals the timestamp, so verification goes past the timestamp check
+		err := VerifyAlfaSignature(signature, timestamp, time.Unix(timestamp, 0), publicPem, wrapperspb.Bytes(body), service, method)
+		requireVerificationError(t, err)
+	})
+}
+
+func FuzzVerifyBravoSignature(f *testing.F) {
+	hashedSecret, err := HashBravoSecretWithDate(GenerateBravoSecret(), GetDateOfTimestamp(verifyTestTimestamp))
+	require.NoError(f, err)
+	signature, err := SignBravoWithHashedSecret(verifyTestTimestamp, hashedSecret, wrapperspb.Bytes([]byte("body")), "Moab", "GetQueue")
+	require.NoError(f, err)
+
+	f.Add(signature, verifyTestTimestamp, hashedSecret, []byte("body"), "Moab", "GetQueue")
+	f.Add("", int64(0), []byte{}, []byte{}, "", "")
+	f.Add("0g", int64(-1), []byte{1}, []byte{0xff}, "Moab", "GetQueue")
+
+	f.Fuzz(func(t *testing.T, signature string, timestamp int64, hashedSecret []byte, body []byte, service string, method string) {
+		err := VerifyBravoSignature(signature, timestamp, time.Unix(timestamp, 0), hashedSecret, wrapperspb.Bytes(body), service, method)
+		requireVerificationError(t, err)
+	})
+}
+
+func FuzzVerifyCharlieSignature(f *testing.F) {
+	hashedSecret, err := HashCharlieSecret(GenerateCharlieSecret(), GetDateOfTimestamp(verifyTestTimestamp), "Moab")
+	require.NoError(f, err)
+	signature, err := SignCharlieWithHashedSecret(verifyTestTimestamp, hashedSecret, wrapperspb.Bytes([]byte("body")), "Moab", "GetQueue")
+	require.NoError(f, err)
+
+	f.Add(signature, verifyTestTimestamp, hashedSecret, []byte("body"), "Moab", "GetQueue")
+	f.Add("", int64(0), []byte{}, []byte{}, "", "")
+
+	f.Fuzz(func(t *testing.T, signature string, timestamp int64, hashedSecret []byte, body []byte, service string, method string) {
+		err := VerifyCharlieSignature(signature, timestamp, time.Unix(timestamp, 0), hashedSecret, wrapperspb.Bytes(body), service, method)
+		requireVerificationError(t, err)
+	})
+}
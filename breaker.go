@@ -0,0 +1,213 @@
+package evrblk
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is a state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitStateClosed lets all calls through
+	CircuitStateClosed CircuitState = iota
+
+	// CircuitStateOpen fails all calls right away with CircuitOpen error code
+	CircuitStateOpen
+
+	// CircuitStateHalfOpen lets a limited number of trial calls through to check whether an endpoint has recovered
+	CircuitStateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitStateClosed:
+		return "closed"
+	case CircuitStateOpen:
+		return "open"
+	case CircuitStateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitGeneration identifies a period between state changes of a circuit. Outcomes of calls allowed in an earlier
+// generation say nothing about the current state and are ignored.
+type CircuitGeneration uint64
+
+// CircuitBreakerPolicy defines when a circuit breaker opens and closes. Calls failed with InternalFailure or Timeout
+// error codes are failures, all other outcomes are successes.
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is a number of consecutive failures which opens a circuit
+	FailureThreshold int
+
+	// OpenTimeout is how long a circuit stays open before it lets trial calls through
+	OpenTimeout time.Duration
+
+	// HalfOpenCalls is a number of trial calls in half-open state. A circuit closes when all of them succeed and opens
+	// again on the first failure.
+	HalfOpenCalls int
+}
+
+// DefaultCircuitBreakerPolicy opens a circuit after 5 consecutive failures for 30 seconds and closes it after a
+// successful trial call
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	FailureThreshold: 5,
+	OpenTimeout:      time.Second * 30,
+	HalfOpenCalls:    1,
+}
+
+// WithCircuitBreaker enables a circuit breaker with a given policy. Generated clients keep a circuit per service and
+// method class (reads and writes), and fail fast with CircuitOpen error code while it is open instead of waiting for a
+// degraded endpoint.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) ClientOption {
+	return func(c *ClientConfig) {
+		c.CircuitBreaker = NewCircuitBreaker(policy)
+	}
+}
+
+// CircuitBreaker keeps a circuit per service and method class. It is safe for concurrent use.
+type CircuitBreaker struct {
+	policy CircuitBreakerPolicy
+
+	mu       sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+type circuitKey struct {
+	service string
+	class   string
+}
+
+type circuit struct {
+	state      CircuitState
+	generation CircuitGeneration
+	failures   int
+	openedAt   time.Time
+
+	// trials are trial calls let through in half-open state, pending are those of them still in flight
+	trials  int
+	pending int
+}
+
+// NewCircuitBreaker creates a circuit breaker with a given policy, all circuits are closed
+func NewCircuitBreaker(policy CircuitBreakerPolicy) *CircuitBreaker {
+	return &CircuitBreaker{
+		policy:   policy,
+		circuits: make(map[circuitKey]*circuit),
+	}
+}
+
+// Allow checks whether a call can be sent and returns the current state and generation of a circuit. Every allowed
+// call must be followed by Record or Cancel with the returned generation.
+func (b *CircuitBreaker) Allow(service string, class string) (CircuitState, CircuitGeneration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(service, class)
+	if c.state == CircuitStateOpen && time.Since(c.openedAt) >= b.policy.OpenTimeout {
+		b.transition(c, CircuitStateHalfOpen)
+	}
+
+	switch c.state {
+	case CircuitStateOpen:
+		return c.state, c.generation, false
+	case CircuitStateHalfOpen:
+		if c.trials >= max(b.policy.HalfOpenCalls, 1) {
+			return c.state, c.generation, false
+		}
+		c.trials++
+		c.pending++
+		return c.state, c.generation, true
+	default:
+		return c.state, c.generation, true
+	}
+}
+
+// Record records an outcome of a call allowed in a given generation and returns the new state of a circuit. Outcomes
+// of calls allowed before the circuit has changed its state are ignored.
+func (b *CircuitBreaker) Record(service string, class string, generation CircuitGeneration, err error) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(service, class)
+	if generation != c.generation {
+		return c.state
+	}
+	failure := isCircuitFailure(err)
+
+	switch c.state {
+	case CircuitStateClosed:
+		if !failure {
+			c.failures = 0
+		} else if c.failures++; c.failures >= b.policy.FailureThreshold {
+			b.open(c)
+		}
+	case CircuitStateHalfOpen:
+		if c.pending > 0 {
+			c.pending--
+		}
+		if failure {
+			b.open(c)
+		} else if c.pending <= 0 && c.trials >= max(b.policy.HalfOpenCalls, 1) {
+			b.transition(c, CircuitStateClosed)
+		}
+	}
+
+	return c.state
+}
+
+// Cancel releases a call allowed in a given generation without an outcome (e.g. canceled by a caller), so it is not
+// counted either way
+func (b *CircuitBreaker) Cancel(service string, class string, generation CircuitGeneration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(service, class)
+	if generation == c.generation && c.state == CircuitStateHalfOpen && c.pending > 0 {
+		c.pending--
+		c.trials--
+	}
+}
+
+// State returns the current state of a circuit
+func (b *CircuitBreaker) State(service string, class string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.circuit(service, class).state
+}
+
+func (b *CircuitBreaker) circuit(service string, class string) *circuit {
+	key := circuitKey{service: service, class: class}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+func (b *CircuitBreaker) open(c *circuit) {
+	b.transition(c, CircuitStateOpen)
+	c.openedAt = time.Now()
+}
+
+// transition changes a state of a circuit and starts a new generation, so calls still in flight are not counted
+func (b *CircuitBreaker) transition(c *circuit, state CircuitState) {
+	c.state = state
+	c.generation++
+	c.failures = 0
+	c.trials = 0
+	c.pending = 0
+}
+
+func isCircuitFailure(err error) bool {
+	var evrblkErr *Error
+	if !errors.As(err, &evrblkErr) {
+		return err != nil
+	}
+	return evrblkErr.Code == InternalFailure || evrblkErr.Code == Timeout
+}
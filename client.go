@@ -0,0 +1,120 @@
+package evrblk
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultUserAgent = "evrblk-go"
+
+// ClientConfig is configuration of generated gRPC clients (e.g. moab.NewMoabGrpcClient), built from ClientOption.
+// Clients connect with TLS and system root certificates by default.
+type ClientConfig struct {
+	// TLSConfig is used for transport security. Nil means system root certificates.
+	TLSConfig *tls.Config
+
+	// Insecure disables transport security (for local development and tests)
+	Insecure bool
+
+	// UserAgent is sent in user-agent header, followed by gRPC version
+	UserAgent string
+
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+
+	// DialOptions are applied last, so they override all options above
+	DialOptions []grpc.DialOption
+
+	RetryPolicy RetryPolicy
+
+	// Timeouts are applied to calls whose contexts have no deadline
+	Timeouts Timeouts
+
+	// WaitForReady makes calls wait for a connection to become ready instead of failing while a server is unreachable
+	WaitForReady bool
+
+	// RateLimiter, if set, throttles every attempt of a unary call and every opening of a stream before it is sent
+	RateLimiter RateLimiter
+
+	// CircuitBreaker, if set, fails calls fast while a service endpoint is failing
+	CircuitBreaker *CircuitBreaker
+
+	// Hedging, if set, sends a second attempt of slow idempotent reads
+	Hedging *HedgingPolicy
+}
+
+// RateLimiter throttles calls of generated clients (see package limiter)
+type RateLimiter interface {
+	// Wait blocks until a call can be sent, or returns an error if the context is done first
+	Wait(ctx context.Context, service string, method string, request proto.Message) error
+}
+
+// ClientOption configures generated gRPC clients
+type ClientOption func(*ClientConfig)
+
+// WithTLSConfig sets TLS configuration, e.g. with custom root certificates or a client certificate
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *ClientConfig) {
+		c.TLSConfig = config
+		c.Insecure = false
+	}
+}
+
+// WithInsecure disables transport security. Only use it to connect to local servers.
+func WithInsecure() ClientOption {
+	return func(c *ClientConfig) {
+		c.Insecure = true
+	}
+}
+
+// WithUserAgent sets user-agent header ("evrblk-go" by default)
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *ClientConfig) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithUnaryInterceptors adds unary interceptors, which are called in the given order. Interceptors see signed calls.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) ClientOption {
+	return func(c *ClientConfig) {
+		c.UnaryInterceptors = append(c.UnaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors, which are called in the given order
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) ClientOption {
+	return func(c *ClientConfig) {
+		c.StreamInterceptors = append(c.StreamInterceptors, interceptors...)
+	}
+}
+
+// WithDialOptions adds arbitrary gRPC dial options
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *ClientConfig) {
+		c.DialOptions = append(c.DialOptions, opts...)
+	}
+}
+
+// WithRateLimiter sets a rate limiter which throttles calls of a client before they are sent
+func WithRateLimiter(limiter RateLimiter) ClientOption {
+	return func(c *ClientConfig) {
+		c.RateLimiter = limiter
+	}
+}
+
+// NewClientConfig creates client configuration with defaults and given options applied
+func NewClientConfig(opts ...ClientOption) *ClientConfig {
+	c := &ClientConfig{
+		UserAgent:    defaultUserAgent,
+		RetryPolicy:  NoRetries,
+		Timeouts:     NoTimeouts,
+		WaitForReady: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,32 @@
+package evrblk
+
+import (
+	"time"
+)
+
+// Clock provides current time to request signers and signature verifiers. It can be replaced to sign requests at
+// deterministic times in tests or to compensate a known clock skew of a host.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct {
+}
+
+var _ Clock = &systemClock{}
+
+func (c *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewSystemClock creates a new Clock which returns local system time
+func NewSystemClock() Clock {
+	return &systemClock{}
+}
+
+// ClockFunc is an adapter to use ordinary functions as Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
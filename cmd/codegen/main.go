@@ -51,15 +51,7 @@ func generateClient(serviceName string, packagePath string, packageName string,
 	// API interface
 	f.Type().Id(apiType).InterfaceFunc(func(g *Group) {
 		for _, m := range serviceDesc.Methods {
-			g.Id(m.MethodName).Params(
-				Id("ctx").Qual("context", "Context"),
-				Id("request").Op("*").Id(m.MethodName+"Request"),
-			).Params(
-				List(
-					Op("*").Id(m.MethodName+"Response"),
-					Error(),
-				),
-			)
+			g.Id(m.MethodName).Params(methodParams(m)...).Params(methodResults(m)...)
 		}
 	})
 
@@ -68,6 +60,7 @@ func generateClient(serviceName string, packagePath string, packageName string,
 		Id("grpc").Id(grpcServiceName+"Client"),
 		Id("conn").Op("*").Qual("google.golang.org/grpc", "ClientConn"),
 		Id("signer").Qual("github.com/evrblk/evrblk-go", "RequestSigner"),
+		Id("config").Op("*").Qual("github.com/evrblk/evrblk-go", "ClientConfig"),
 	)
 	f.Line()
 
@@ -87,6 +80,7 @@ func generateClient(serviceName string, packagePath string, packageName string,
 			Id("grpc"):   Id("c").Dot("grpc"),
 			Id("conn"):   Id("c").Dot("conn"),
 			Id("signer"): Id("signer"),
+			Id("config"): Id("c").Dot("config"),
 		})),
 	)
 	f.Line()
@@ -101,62 +95,46 @@ func generateClient(serviceName string, packagePath string, packageName string,
 
 	// gRPC methods
 	for _, m := range serviceDesc.Methods {
+		if m.IsClientStream || m.IsServerStream {
+			f.Func().Params(
+				Id("c").Op("*").Id(grpcClientType),
+			).Id(m.MethodName).Params(methodParams(m)...).Params(methodResults(m)...).Block(
+				Return(streamCall(serviceName, m)),
+			)
+			f.Line()
+			continue
+		}
+
 		f.Func().Params(
 			Id("c").Op("*").Id(grpcClientType),
-		).Id(m.MethodName).Params(
-			Id("ctx").Qual("context", "Context"),
-			Id("request").Op("*").Id(m.MethodName+"Request"),
-		).Params(
-			Op("*").Id(m.MethodName+"Response"),
-			Error(),
-		).Block(
-			// Increment TotalRequestsCounter metric
-			Qual("github.com/evrblk/evrblk-go/internal", "TotalRequestsCounter").
-				Dot("WithLabelValues").Call(Lit(serviceName), Lit(m.MethodName)).Dot("Inc").Call(),
-
-			// Measure RequestsDuration metric
-			Defer().Qual("github.com/evrblk/evrblk-go/internal", "MeasureSince").Call(
-				Qual("github.com/evrblk/evrblk-go/internal", "RequestsDuration").
-					Dot("WithLabelValues").Call(Lit(serviceName), Lit(m.MethodName)),
-				Qual("time", "Now").Call(),
-			),
-			Line(),
-
-			// Sign request and update context
-			List(Id("signedCtx"), Err()).Op(":=").Id("c").Dot("signer").Dot("Sign").Call(
-				Id("ctx"), Id("request"), Lit(serviceName), Lit(m.MethodName),
-			),
-			If(
-				Err().Op("!=").Nil(),
-			).Block(
-				Return(List(Nil(), Err())),
-			),
-			Line(),
-
-			// Call gRPC method
-			List(Id("resp"), Err()).Op(":=").Id("c").Dot("grpc").Dot(m.MethodName).Call(
-				Id("signedCtx"),
-				Id("request"),
-				Qual("google.golang.org/grpc", "WaitForReady").Call(True()),
-			),
-
-			If(
-				Err().Op("!=").Nil(),
-			).Block(
-				// Increment FailedRequestsCounter metric
-				Qual("github.com/evrblk/evrblk-go/internal", "FailedRequestsCounter").Dot("WithLabelValues").
-					Call(
-						Lit(serviceName),
-						Lit(m.MethodName),
-						Qual("github.com/evrblk/evrblk-go/internal", "MetricLabelFromGrpcError").Call(Err()),
-					).Dot("Inc").Call(),
-			),
-			Line(),
-
-			Return(
-				List(Id("resp"), Qual("github.com/evrblk/evrblk-go/internal", "ErrorFromRpcError").Call(Err())),
-			),
-		)
+		).Id(m.MethodName).Params(methodParams(m)...).Params(methodResults(m)...).BlockFunc(func(g *Group) {
+			idempotent := Lit(m.Idempotent)
+			if m.DedupeEntriesField != "" {
+				// Retrying is safe only if there are entries and the server can dedupe every one of them
+				g.Id("idempotent").Op(":=").Len(Id("request").Dot(m.DedupeEntriesField)).Op(">").Lit(0)
+				g.For(List(Id("_"), Id("entry")).Op(":=").Range().Id("request").Dot(m.DedupeEntriesField)).Block(
+					If(Id("entry").Dot("DedupeKey").Op("==").Lit("")).Block(
+						Id("idempotent").Op("=").False(),
+					),
+				)
+				idempotent = Id("idempotent")
+			}
+
+			// Sign, call and retry
+			g.Return(
+				Qual("github.com/evrblk/evrblk-go/internal", "Invoke").Call(
+					Id("ctx"),
+					Id("c").Dot("config"),
+					Id("c").Dot("signer"),
+					Lit(serviceName),
+					Lit(m.MethodName),
+					Id("request"),
+					idempotent,
+					Qual("github.com/evrblk/evrblk-go", m.Category),
+					Id("c").Dot("grpc").Dot(m.MethodName),
+				),
+			)
+		})
 		f.Line()
 	}
 
@@ -164,29 +142,79 @@ func generateClient(serviceName string, packagePath string, packageName string,
 	f.Func().Id("New"+grpcClientType).Params(
 		Id("address").String(),
 		Id("signer").Qual("github.com/evrblk/evrblk-go", "RequestSigner"),
+		Id("opts").Op("...").Qual("github.com/evrblk/evrblk-go", "ClientOption"),
 	).Params(
 		Op("*").Id(grpcClientType),
+		Error(),
 	).Block(
-		List(Id("conn"), Err()).Op(":=").Qual("google.golang.org/grpc", "NewClient").Call(
+		Id("config").Op(":=").Qual("github.com/evrblk/evrblk-go", "NewClientConfig").Call(Id("opts").Op("...")),
+		List(Id("conn"), Err()).Op(":=").Qual("github.com/evrblk/evrblk-go/internal", "Dial").Call(
 			Id("address"),
-			Qual("google.golang.org/grpc", "WithTransportCredentials").Call(
-				Qual("google.golang.org/grpc/credentials/insecure", "NewCredentials").Call(),
-			),
+			Id("config"),
 		),
 		If(
 			Err().Op("!=").Nil(),
 		).Block(
-			Qual("log", "Fatalf").Call(Lit("did not connect: %v"), Err()),
+			Return(Nil(), Err()),
 		),
 		Return(
 			Op("&").Id(grpcClientType).Values(Dict{
 				Id("conn"):   Id("conn"),
 				Id("grpc"):   Id("New" + grpcServiceName + "Client").Call(Id("conn")),
 				Id("signer"): Id("signer"),
+				Id("config"): Id("config"),
 			}),
+			Nil(),
 		),
 	)
 	f.Line()
 
 	return fmt.Sprintf("%#v", f)
 }
+
+// methodParams returns parameters of a client method: a context, and a request unless a client sends a stream of
+// requests
+func methodParams(m ProtoMethodDesc) []Code {
+	params := []Code{Id("ctx").Qual("context", "Context")}
+	if !m.IsClientStream {
+		params = append(params, Id("request").Op("*").Id(m.MethodName+"Request"))
+	}
+	return params
+}
+
+// methodResults returns results of a client method: a response for unary calls, or a gRPC stream for streaming
+// calls
+func methodResults(m ProtoMethodDesc) []Code {
+	request := Id(m.MethodName + "Request")
+	response := Id(m.MethodName + "Response")
+
+	switch {
+	case m.IsClientStream && m.IsServerStream:
+		return []Code{Qual("google.golang.org/grpc", "BidiStreamingClient").Types(request, response), Error()}
+	case m.IsClientStream:
+		return []Code{Qual("google.golang.org/grpc", "ClientStreamingClient").Types(request, response), Error()}
+	case m.IsServerStream:
+		return []Code{Qual("google.golang.org/grpc", "ServerStreamingClient").Types(response), Error()}
+	default:
+		return []Code{Op("*").Add(response), Error()}
+	}
+}
+
+// streamCall returns a call of an internal helper which signs, opens and meters a stream with client configuration
+func streamCall(serviceName string, m ProtoMethodDesc) Code {
+	helper := "ServerStream"
+	args := []Code{Id("ctx"), Id("c").Dot("config"), Id("c").Dot("signer"), Lit(serviceName), Lit(m.MethodName)}
+	switch {
+	case m.IsClientStream && m.IsServerStream:
+		helper = "BidiStream"
+	case m.IsClientStream:
+		helper = "ClientStream"
+	default:
+		args = append(args, Id("request"))
+	}
+	args = append(args, Qual("github.com/evrblk/evrblk-go", m.Category), Id("c").Dot("grpc").Dot(m.MethodName))
+
+	return Qual("github.com/evrblk/evrblk-go/internal", helper).
+		Types(Id(m.MethodName+"Request"), Id(m.MethodName+"Response")).
+		Call(args...)
+}
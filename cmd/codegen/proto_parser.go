@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -24,6 +25,16 @@ type ProtoMethodDesc struct {
 	OutputType     string
 	IsClientStream bool
 	IsServerStream bool
+
+	// Idempotent methods (Get* and List*) can always be retried
+	Idempotent bool
+
+	// DedupeEntriesField is a Go name of a repeated field of a request, entries of which have dedupe_key. Such calls
+	// can be retried when every entry has a dedupe key.
+	DedupeEntriesField string
+
+	// Category is a name of an evrblk.MethodCategory constant, which selects a default timeout of calls
+	Category string
 }
 
 // ReadProtoFileAndExtractServices reads a proto file and extracts all gRPC service descriptors
@@ -128,8 +139,64 @@ func extractMethods(service protoreflect.ServiceDescriptor) []ProtoMethodDesc {
 			IsClientStream: method.IsStreamingClient(),
 			IsServerStream: method.IsStreamingServer(),
 		}
+		methodDesc.Idempotent, methodDesc.DedupeEntriesField = methodIdempotency(method)
+		methodDesc.Category = methodCategory(method)
 		methods = append(methods, methodDesc)
 	}
 
 	return methods
 }
+
+// methodIdempotency classifies a method for retries: reading methods are idempotent, methods with a repeated field of
+// entries with dedupe_key are idempotent when every entry has a dedupe key, all others are not
+func methodIdempotency(method protoreflect.MethodDescriptor) (bool, string) {
+	name := string(method.Name())
+	if strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List") {
+		return true, ""
+	}
+
+	fields := method.Input().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if !field.IsList() || field.Message() == nil {
+			continue
+		}
+		if dedupeKey := field.Message().Fields().ByName("dedupe_key"); dedupeKey != nil && dedupeKey.Kind() == protoreflect.StringKind {
+			return false, goCamelCase(string(field.Name()))
+		}
+	}
+
+	return false, ""
+}
+
+// methodCategory classifies a method for default timeouts: Wait* methods are long polls, methods managing resources
+// (Create*, Get*, Update*, Delete* and List*) are control plane, except those working with tasks, all others are data
+// plane
+func methodCategory(method protoreflect.MethodDescriptor) string {
+	name := string(method.Name())
+	if strings.HasPrefix(name, "Wait") {
+		return "LongPollMethod"
+	}
+	if strings.Contains(name, "Task") || strings.Contains(name, "Subtask") {
+		return "DataPlaneMethod"
+	}
+	for _, prefix := range []string{"Create", "Get", "Update", "Delete", "List"} {
+		if strings.HasPrefix(name, prefix) {
+			return "ControlPlaneMethod"
+		}
+	}
+	return "DataPlaneMethod"
+}
+
+// goCamelCase converts a snake_case proto field name to a Go field name the same way as protoc-gen-go does for
+// simple names
+func goCamelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
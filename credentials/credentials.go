@@ -0,0 +1,141 @@
+// Package credentials finds API keys for request signers: in explicit values, environment variables, a shared
+// credentials file with named profiles, or a PEM file with an Alfa private key.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	evrblk "github.com/evrblk/evrblk-go"
+)
+
+const (
+	ApiKeyIdEnvVar  = "EVRBLK_API_KEY_ID"
+	ApiSecretEnvVar = "EVRBLK_API_SECRET"
+	ProfileEnvVar   = "EVRBLK_PROFILE"
+
+	defaultProfile = "default"
+)
+
+// ErrNoCredentials is returned (wrapped) by a provider which has no credentials in its source. A chain of providers
+// moves on to the next provider only on this error.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Credentials are an API key ID and its secret: a private PEM for Alfa keys, a secret for Bravo and Charlie keys.
+type Credentials struct {
+	ApiKeyId  string
+	ApiSecret string
+
+	// Source is a name of the provider which found these credentials
+	Source string
+}
+
+// Provider finds credentials in some source
+type Provider interface {
+	// Name is a human-readable name of the provider used in errors
+	Name() string
+
+	// Retrieve returns credentials. If the source has no credentials, an error wrapping ErrNoCredentials is returned.
+	Retrieve() (*Credentials, error)
+}
+
+// NewRequestSigner retrieves credentials from a provider and creates a request signer for them (see
+// evrblk.NewRequestSigner).
+func NewRequestSigner(provider Provider, opts ...evrblk.SignerOption) (evrblk.RequestSigner, error) {
+	creds, err := provider.Retrieve()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := evrblk.NewRequestSigner(creds.ApiKeyId, creds.ApiSecret, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", creds.Source, err)
+	}
+
+	return signer, nil
+}
+
+// NewDefaultRequestSigner creates a request signer with credentials from the default chain of providers (see
+// NewDefaultChain).
+func NewDefaultRequestSigner(opts ...evrblk.SignerOption) (evrblk.RequestSigner, error) {
+	return NewRequestSigner(NewDefaultChain(), opts...)
+}
+
+type chainProvider struct {
+	providers []Provider
+}
+
+var _ Provider = &chainProvider{}
+
+func (p *chainProvider) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		names = append(names, provider.Name())
+	}
+	return fmt.Sprintf("chain(%s)", strings.Join(names, ", "))
+}
+
+func (p *chainProvider) Retrieve() (*Credentials, error) {
+	reasons := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		creds, err := provider.Retrieve()
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return nil, fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", provider.Name(), err))
+	}
+
+	return nil, fmt.Errorf("%w found, tried: %s", ErrNoCredentials, strings.Join(reasons, "; "))
+}
+
+// NewChain creates a provider which tries given providers in order and returns the first found credentials. It stops
+// at the first provider which fails with an error other than ErrNoCredentials (e.g. a malformed credentials file).
+func NewChain(providers ...Provider) Provider {
+	return &chainProvider{
+		providers: providers,
+	}
+}
+
+// NewDefaultChain creates a chain of environment variables provider and shared credentials file provider with a
+// profile from EVRBLK_PROFILE environment variable (or "default").
+func NewDefaultChain() Provider {
+	return NewChain(
+		NewEnvProvider(),
+		NewProfileProvider("", ""),
+	)
+}
+
+type staticProvider struct {
+	apiKeyId  string
+	apiSecret string
+}
+
+var _ Provider = &staticProvider{}
+
+func (p *staticProvider) Name() string {
+	return "static"
+}
+
+func (p *staticProvider) Retrieve() (*Credentials, error) {
+	if p.apiKeyId == "" || p.apiSecret == "" {
+		return nil, fmt.Errorf("%w: API key ID or secret is empty", ErrNoCredentials)
+	}
+
+	return &Credentials{
+		ApiKeyId:  p.apiKeyId,
+		ApiSecret: p.apiSecret,
+		Source:    p.Name(),
+	}, nil
+}
+
+// NewStaticProvider creates a provider with explicitly given credentials
+func NewStaticProvider(apiKeyId string, apiSecret string) Provider {
+	return &staticProvider{
+		apiKeyId:  apiKeyId,
+		apiSecret: apiSecret,
+	}
+}
@@ -0,0 +1,180 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets all environment variables read by providers for the duration of a test
+func clearEnv(t *testing.T) {
+	for _, name := range []string{ApiKeyIdEnvVar, ApiSecretEnvVar, ProfileEnvVar, CredentialsFileEnvVar} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.WriteFile(path, []byte(content), 0600)
+	require.NoError(t, err)
+}
+
+func TestEnvProvider(t *testing.T) {
+	clearEnv(t)
+	bravoSecret := authn.GenerateBravoSecret()
+
+	_, err := NewEnvProvider().Retrieve()
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	// Only one of variables is a misconfiguration, not an absence of credentials
+	t.Setenv(ApiKeyIdEnvVar, "key_bravo_test")
+	_, err = NewEnvProvider().Retrieve()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoCredentials)
+
+	t.Setenv(ApiSecretEnvVar, bravoSecret)
+	creds, err := NewEnvProvider().Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_bravo_test", creds.ApiKeyId)
+	require.Equal(t, bravoSecret, creds.ApiSecret)
+	require.Equal(t, "environment", creds.Source)
+}
+
+func TestProfileProvider(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials")
+
+	privatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	bravoSecret := authn.GenerateBravoSecret()
+
+	writeFile(t, filepath.Join(dir, "production.pem"), privatePem)
+	writeFile(t, path, `
+# Shared credentials
+[default]
+api_key_id = key_bravo_test
+api_secret = `+bravoSecret+`
+
+[production]
+api_key_id = key_alfa_test
+private_key_file = production.pem
+
+[broken]
+api_key_id = key_alfa_test
+`)
+
+	// Missing file
+	_, err = NewProfileProvider(filepath.Join(dir, "missing"), "").Retrieve()
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	// Default profile
+	creds, err := NewProfileProvider(path, "").Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_bravo_test", creds.ApiKeyId)
+	require.Equal(t, bravoSecret, creds.ApiSecret)
+
+	// Profile from environment, private key file relative to credentials file
+	t.Setenv(ProfileEnvVar, "production")
+	creds, err = NewProfileProvider(path, "").Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_alfa_test", creds.ApiKeyId)
+	require.Equal(t, privatePem, creds.ApiSecret)
+
+	// Credentials file from environment
+	t.Setenv(CredentialsFileEnvVar, path)
+	creds, err = NewProfileProvider("", "default").Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_bravo_test", creds.ApiKeyId)
+
+	// Missing profile
+	_, err = NewProfileProvider(path, "staging").Retrieve()
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	// Profile without a secret
+	_, err = NewProfileProvider(path, "broken").Retrieve()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoCredentials)
+
+	// Malformed file
+	writeFile(t, path, "api_key_id = key_bravo_test\n")
+	_, err = NewProfileProvider(path, "").Retrieve()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestPemFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.pem")
+
+	privatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	writeFile(t, path, privatePem)
+
+	creds, err := NewPemFileProvider("key_alfa_test", path).Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_alfa_test", creds.ApiKeyId)
+	require.Equal(t, privatePem, creds.ApiSecret)
+
+	// Explicitly given file must exist
+	_, err = NewPemFileProvider("key_alfa_test", filepath.Join(dir, "missing.pem")).Retrieve()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestChain(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	bravoSecret := authn.GenerateBravoSecret()
+
+	chain := NewChain(
+		NewEnvProvider(),
+		NewProfileProvider(filepath.Join(dir, "credentials"), ""),
+		NewStaticProvider("key_bravo_test", bravoSecret),
+	)
+
+	// Falls through to static credentials
+	creds, err := chain.Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "static", creds.Source)
+
+	// Environment takes precedence
+	t.Setenv(ApiKeyIdEnvVar, "key_bravo_env")
+	t.Setenv(ApiSecretEnvVar, bravoSecret)
+	creds, err = chain.Retrieve()
+	require.NoError(t, err)
+	require.Equal(t, "key_bravo_env", creds.ApiKeyId)
+
+	// Misconfigured provider stops the chain
+	t.Setenv(ApiSecretEnvVar, "")
+	_, err = chain.Retrieve()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoCredentials)
+	require.Contains(t, err.Error(), "environment")
+
+	// All tried providers are listed
+	clearEnv(t)
+	_, err = NewChain(NewEnvProvider(), NewProfileProvider(filepath.Join(dir, "credentials"), "")).Retrieve()
+	require.ErrorIs(t, err, ErrNoCredentials)
+	require.Contains(t, err.Error(), "environment")
+	require.Contains(t, err.Error(), "profile default in "+filepath.Join(dir, "credentials"))
+}
+
+func TestNewRequestSigner(t *testing.T) {
+	privatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+
+	signer, err := NewRequestSigner(NewStaticProvider("key_alfa_test", privatePem))
+	require.NoError(t, err)
+	require.NotNil(t, signer)
+
+	// Errors of signer creation name the source of credentials
+	_, err = NewRequestSigner(NewStaticProvider("key_alfa_test", "not a pem"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "static")
+
+	_, err = NewRequestSigner(NewStaticProvider("", ""))
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
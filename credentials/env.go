@@ -0,0 +1,41 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+)
+
+type envProvider struct {
+}
+
+var _ Provider = &envProvider{}
+
+func (p *envProvider) Name() string {
+	return "environment"
+}
+
+func (p *envProvider) Retrieve() (*Credentials, error) {
+	apiKeyId := os.Getenv(ApiKeyIdEnvVar)
+	apiSecret := os.Getenv(ApiSecretEnvVar)
+
+	switch {
+	case apiKeyId == "" && apiSecret == "":
+		return nil, fmt.Errorf("%w: %s and %s are not set", ErrNoCredentials, ApiKeyIdEnvVar, ApiSecretEnvVar)
+	case apiKeyId == "":
+		return nil, fmt.Errorf("%s is set, but %s is not", ApiSecretEnvVar, ApiKeyIdEnvVar)
+	case apiSecret == "":
+		return nil, fmt.Errorf("%s is set, but %s is not", ApiKeyIdEnvVar, ApiSecretEnvVar)
+	}
+
+	return &Credentials{
+		ApiKeyId:  apiKeyId,
+		ApiSecret: apiSecret,
+		Source:    p.Name(),
+	}, nil
+}
+
+// NewEnvProvider creates a provider which reads credentials from EVRBLK_API_KEY_ID and EVRBLK_API_SECRET environment
+// variables
+func NewEnvProvider() Provider {
+	return &envProvider{}
+}
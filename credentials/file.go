@@ -0,0 +1,205 @@
+package credentials
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	CredentialsFileEnvVar = "EVRBLK_CREDENTIALS_FILE"
+
+	apiKeyIdField       = "api_key_id"
+	apiSecretField      = "api_secret"
+	privateKeyFileField = "private_key_file"
+)
+
+type profileProvider struct {
+	path    string
+	profile string
+}
+
+var _ Provider = &profileProvider{}
+
+func (p *profileProvider) Name() string {
+	return fmt.Sprintf("profile %s in %s", p.profileName(), p.filePath())
+}
+
+func (p *profileProvider) Retrieve() (*Credentials, error) {
+	path := p.filePath()
+	profile := p.profileName()
+
+	profiles, err := readCredentialsFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s does not exist", ErrNoCredentials, path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	fields, ok := profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("%w: profile %s not found", ErrNoCredentials, profile)
+	}
+
+	apiKeyId := fields[apiKeyIdField]
+	if apiKeyId == "" {
+		return nil, fmt.Errorf("profile %s: %s is not set", profile, apiKeyIdField)
+	}
+
+	apiSecret := fields[apiSecretField]
+	privateKeyFile := fields[privateKeyFileField]
+	switch {
+	case apiSecret != "" && privateKeyFile != "":
+		return nil, fmt.Errorf("profile %s: only one of %s and %s can be set", profile, apiSecretField, privateKeyFileField)
+	case privateKeyFile != "":
+		privateKeyFile = expandPath(privateKeyFile, filepath.Dir(path))
+		privatePem, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile, err)
+		}
+		apiSecret = string(privatePem)
+	case apiSecret == "":
+		return nil, fmt.Errorf("profile %s: neither %s nor %s is set", profile, apiSecretField, privateKeyFileField)
+	}
+
+	return &Credentials{
+		ApiKeyId:  apiKeyId,
+		ApiSecret: apiSecret,
+		Source:    p.Name(),
+	}, nil
+}
+
+func (p *profileProvider) filePath() string {
+	if p.path != "" {
+		return p.path
+	}
+	if path := os.Getenv(CredentialsFileEnvVar); path != "" {
+		return path
+	}
+	return expandPath(filepath.Join("~", ".evrblk", "credentials"), "")
+}
+
+func (p *profileProvider) profileName() string {
+	if p.profile != "" {
+		return p.profile
+	}
+	if profile := os.Getenv(ProfileEnvVar); profile != "" {
+		return profile
+	}
+	return defaultProfile
+}
+
+// NewProfileProvider creates a provider which reads a named profile from a shared credentials file. Empty path means
+// EVRBLK_CREDENTIALS_FILE environment variable or ~/.evrblk/credentials, empty profile means EVRBLK_PROFILE
+// environment variable or "default". The file has INI format:
+//
+//	[default]
+//	api_key_id = key_bravo_...
+//	api_secret = ...
+//
+//	[production]
+//	api_key_id = key_alfa_...
+//	private_key_file = ~/.evrblk/production.pem
+//
+// Relative private_key_file paths are resolved against the directory of the credentials file.
+func NewProfileProvider(path string, profile string) Provider {
+	return &profileProvider{
+		path:    path,
+		profile: profile,
+	}
+}
+
+type pemFileProvider struct {
+	apiKeyId string
+	path     string
+}
+
+var _ Provider = &pemFileProvider{}
+
+func (p *pemFileProvider) Name() string {
+	return fmt.Sprintf("PEM file %s", p.path)
+}
+
+func (p *pemFileProvider) Retrieve() (*Credentials, error) {
+	privatePem, err := os.ReadFile(expandPath(p.path, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		ApiKeyId:  p.apiKeyId,
+		ApiSecret: string(privatePem),
+		Source:    p.Name(),
+	}, nil
+}
+
+// NewPemFileProvider creates a provider for an Alfa API key with a private key stored in a PEM file. Unlike other
+// providers, a missing file is an error, not an absence of credentials.
+func NewPemFileProvider(apiKeyId string, path string) Provider {
+	return &pemFileProvider{
+		apiKeyId: apiKeyId,
+		path:     path,
+	}
+}
+
+// readCredentialsFile parses an INI file into fields of profiles
+func readCredentialsFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("%s:%d: empty profile name", path, lineNumber)
+			}
+			current = make(map[string]string)
+			profiles[name] = current
+
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+			}
+			if current == nil {
+				return nil, fmt.Errorf("%s:%d: field outside of a profile", path, lineNumber)
+			}
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return profiles, scanner.Err()
+}
+
+// expandPath replaces leading ~ with a home directory and resolves relative paths against base (if not empty)
+func expandPath(path string, base string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	if base != "" && !filepath.IsAbs(path) {
+		return filepath.Join(base, path)
+	}
+	return path
+}
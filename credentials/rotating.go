@@ -0,0 +1,300 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/internal"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultRefreshInterval = time.Minute
+	defaultOverlap         = time.Minute * 10
+
+	// fallbackProbeInterval is how long the previous key is used after a fallback before the current key is tried again
+	fallbackProbeInterval = time.Minute
+)
+
+type rotatingSignerOptions struct {
+	refreshInterval time.Duration
+	overlap         time.Duration
+	signerOptions   []evrblk.SignerOption
+	logger          *slog.Logger
+}
+
+// RotatingSignerOption configures a RotatingSigner
+type RotatingSignerOption func(*rotatingSignerOptions)
+
+// WithRefreshInterval sets how often a provider is polled for new credentials (every minute by default). Zero
+// disables polling, credentials are reloaded only by RotatingSigner.Refresh then.
+func WithRefreshInterval(interval time.Duration) RotatingSignerOption {
+	return func(o *rotatingSignerOptions) {
+		o.refreshInterval = interval
+	}
+}
+
+// WithOverlap sets for how long after a rotation a previous key is kept as a fallback (10 minutes by default). Zero
+// disables the fallback.
+func WithOverlap(overlap time.Duration) RotatingSignerOption {
+	return func(o *rotatingSignerOptions) {
+		o.overlap = overlap
+	}
+}
+
+// WithSignerOptions sets options of request signers created for every loaded key
+func WithSignerOptions(opts ...evrblk.SignerOption) RotatingSignerOption {
+	return func(o *rotatingSignerOptions) {
+		o.signerOptions = opts
+	}
+}
+
+// WithLogger sets a logger for rotation events (slog.Default() by default)
+func WithLogger(logger *slog.Logger) RotatingSignerOption {
+	return func(o *rotatingSignerOptions) {
+		o.logger = logger
+	}
+}
+
+func newRotatingSignerOptions(opts []RotatingSignerOption) *rotatingSignerOptions {
+	o := &rotatingSignerOptions{
+		refreshInterval: defaultRefreshInterval,
+		overlap:         defaultOverlap,
+		logger:          slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// rotatingKey is a loaded API key and a signer for it
+type rotatingKey struct {
+	creds  *Credentials
+	signer evrblk.RequestSigner
+}
+
+// RotatingSigner is a request signer which reloads credentials from a provider when they change (e.g. a credentials
+// file is rewritten), so a key can be rotated without rebuilding clients. Until an overlap period after a rotation
+// ends, the previous key is kept: if a request signed with the new key is rejected because the server does not know
+// the key yet (e.g. it has not propagated), the signer falls back to the previous key and the call is retried. The new
+// key is tried again every minute, so a single rejection does not switch the signer to the previous key for good.
+type RotatingSigner struct {
+	provider Provider
+	o        *rotatingSignerOptions
+	now      func() time.Time
+
+	// refreshMu serializes reloads
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	current     *rotatingKey
+	previous    *rotatingKey
+	overlapEnds time.Time
+
+	// fallbackEnds is when the current key is tried again after the signer has fallen back to the previous one
+	fallbackEnds time.Time
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ evrblk.RequestSigner = &RotatingSigner{}
+var _ evrblk.ResponseObserver = &RotatingSigner{}
+var _ evrblk.ClockSkewCorrector = &RotatingSigner{}
+var _ evrblk.StreamSigner = &RotatingSigner{}
+
+// NewRotatingSigner loads credentials from a provider and creates a rotating signer for them. Unless polling is
+// disabled with WithRefreshInterval(0), the provider is polled in background until Close is called.
+func NewRotatingSigner(provider Provider, opts ...RotatingSignerOption) (*RotatingSigner, error) {
+	o := newRotatingSignerOptions(opts)
+
+	key, err := loadKey(provider, o.signerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &RotatingSigner{
+		provider: provider,
+		o:        o,
+		now:      time.Now,
+		current:  key,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if o.refreshInterval > 0 {
+		go s.poll()
+	} else {
+		close(s.done)
+	}
+
+	return s, nil
+}
+
+func (s *RotatingSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
+	return s.active().signer.Sign(ctx, request, service, method)
+}
+
+// SignStream signs opening of a stream with the active key. All messages of the stream are signed with the same key.
+func (s *RotatingSigner) SignStream(ctx context.Context, service string, method string) (context.Context, evrblk.MessageSigner, error) {
+	key := s.active()
+	signer, ok := key.signer.(evrblk.StreamSigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("signer of %s does not support streams", key.creds.ApiKeyId)
+	}
+	return signer.SignStream(ctx, service, method)
+}
+
+// ObserveResponse passes a response to the active signer (e.g. for clock skew correction). If a call was rejected
+// during an overlap period because the server does not know the current key, the signer switches to the previous key
+// for a while and asks for a retry. Other authentication failures (e.g. clock skew or replays) are not fallen back on.
+func (s *RotatingSigner) ObserveResponse(header metadata.MD, err error) bool {
+	key := s.active()
+	if internal.ObserveResponse(key.signer, header, err) {
+		return true
+	}
+
+	if !evrblk.IsApiKeyInvalid(err) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.previous == nil || key != s.current || !now.Before(s.overlapEnds) {
+		return false
+	}
+	s.fallbackEnds = now.Add(fallbackProbeInterval)
+
+	internal.KeyFallbacksCounter.Inc()
+	s.o.logger.Warn("API key rejected, falling back to previous key",
+		"api_key_id", s.current.creds.ApiKeyId,
+		"previous_api_key_id", s.previous.creds.ApiKeyId,
+		"until", s.fallbackEnds,
+		"error", err)
+
+	return true
+}
+
+func (s *RotatingSigner) ClockOffset() time.Duration {
+	if c, ok := s.active().signer.(evrblk.ClockSkewCorrector); ok {
+		return c.ClockOffset()
+	}
+	return 0
+}
+
+// ApiKeyId returns ID of the API key used to sign requests at the moment
+func (s *RotatingSigner) ApiKeyId() string {
+	return s.active().creds.ApiKeyId
+}
+
+// Refresh reloads credentials from the provider and rotates the key if they have changed. On error, the current key
+// is kept.
+func (s *RotatingSigner) Refresh() error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	creds, err := s.provider.Retrieve()
+	if err != nil {
+		return s.rotationFailed(err)
+	}
+
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+
+	if creds.ApiKeyId == current.creds.ApiKeyId && creds.ApiSecret == current.creds.ApiSecret {
+		return nil
+	}
+
+	key, err := newRotatingKey(creds, s.o.signerOptions)
+	if err != nil {
+		return s.rotationFailed(err)
+	}
+
+	s.mu.Lock()
+	s.previous = s.current
+	s.current = key
+	s.overlapEnds = s.now().Add(s.o.overlap)
+	s.fallbackEnds = time.Time{}
+	s.mu.Unlock()
+
+	internal.KeyRotationsCounter.WithLabelValues("success").Inc()
+	s.o.logger.Info("API key rotated",
+		"api_key_id", key.creds.ApiKeyId,
+		"previous_api_key_id", current.creds.ApiKeyId,
+		"source", key.creds.Source)
+
+	return nil
+}
+
+// Close stops polling of the provider
+func (s *RotatingSigner) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// active returns a key to sign requests with: the previous one if the signer has recently fallen back to it during an
+// overlap period, otherwise the current one
+func (s *RotatingSigner) active() *rotatingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	if s.previous != nil && now.Before(s.fallbackEnds) && now.Before(s.overlapEnds) {
+		return s.previous
+	}
+	return s.current
+}
+
+func (s *RotatingSigner) rotationFailed(err error) error {
+	internal.KeyRotationsCounter.WithLabelValues("failure").Inc()
+	s.o.logger.Error("failed to reload API key", "provider", s.provider.Name(), "error", err)
+	return err
+}
+
+func (s *RotatingSigner) poll() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.o.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// Errors are logged and counted, the current key is kept until the next attempt
+			_ = s.Refresh()
+		}
+	}
+}
+
+func loadKey(provider Provider, opts []evrblk.SignerOption) (*rotatingKey, error) {
+	creds, err := provider.Retrieve()
+	if err != nil {
+		return nil, err
+	}
+	return newRotatingKey(creds, opts)
+}
+
+func newRotatingKey(creds *Credentials, opts []evrblk.SignerOption) (*rotatingKey, error) {
+	signer, err := evrblk.NewRequestSigner(creds.ApiKeyId, creds.ApiSecret, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", creds.Source, err)
+	}
+	return &rotatingKey{
+		creds:  creds,
+		signer: signer,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package credentials
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// mutableProvider returns whatever credentials are set at the moment
+type mutableProvider struct {
+	creds *Credentials
+	err   error
+}
+
+func (p *mutableProvider) Name() string {
+	return "mutable"
+}
+
+func (p *mutableProvider) Retrieve() (*Credentials, error) {
+	return p.creds, p.err
+}
+
+func TestRotatingSigner(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+	provider := &mutableProvider{
+		creds: &Credentials{ApiKeyId: "key_bravo_old", ApiSecret: authn.GenerateBravoSecret()},
+	}
+
+	signer, err := NewRotatingSigner(provider, WithRefreshInterval(0), WithOverlap(time.Minute*5), WithLogger(discardLogger))
+	require.NoError(t, err)
+	defer signer.Close()
+	signer.now = func() time.Time { return now }
+
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Nothing has changed
+	require.NoError(t, signer.Refresh())
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Invalid new credentials are not loaded
+	provider.creds = &Credentials{ApiKeyId: "key_bravo_new", ApiSecret: "not base64"}
+	require.Error(t, signer.Refresh())
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Rotation
+	provider.creds = &Credentials{ApiKeyId: "key_bravo_new", ApiSecret: authn.GenerateBravoSecret()}
+	require.NoError(t, signer.Refresh())
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+
+	// Other errors do not cause a fallback, including other authentication failures
+	require.False(t, signer.ObserveResponse(nil, nil))
+	require.False(t, signer.ObserveResponse(nil, status.Error(codes.NotFound, "not found")))
+	require.False(t, signer.ObserveResponse(nil, status.Error(codes.Unauthenticated, "replayed signature")))
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+
+	// New key is unknown to the server, the call is retried with the previous one
+	st, err := status.New(codes.Unauthenticated, "unknown API key").WithDetails(&errdetails.ErrorInfo{
+		Reason: "API_KEY_INVALID",
+		Domain: "evrblk.com",
+	})
+	require.NoError(t, err)
+	rejected := st.Err()
+	require.True(t, signer.ObserveResponse(nil, rejected))
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Previous key is rejected too, no more retries
+	require.False(t, signer.ObserveResponse(nil, rejected))
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// The new key is tried again after a while, and the signer falls back again while it is still unknown
+	now = now.Add(time.Minute)
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+	require.True(t, signer.ObserveResponse(nil, rejected))
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Once the new key is accepted, it stays in use
+	now = now.Add(time.Minute)
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+	require.False(t, signer.ObserveResponse(nil, nil))
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+
+	// Overlap ends, the new key is used for good
+	now = now.Add(time.Minute * 3)
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+	require.False(t, signer.ObserveResponse(nil, rejected))
+	require.Equal(t, "key_bravo_new", signer.ApiKeyId())
+}
+
+func TestRotatingSignerWatchesFile(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials")
+
+	writeFile(t, path, "[default]\napi_key_id = key_bravo_old\napi_secret = "+authn.GenerateBravoSecret()+"\n")
+
+	signer, err := NewRotatingSigner(NewProfileProvider(path, ""), WithRefreshInterval(time.Millisecond*10), WithLogger(discardLogger))
+	require.NoError(t, err)
+	defer signer.Close()
+
+	require.Equal(t, "key_bravo_old", signer.ApiKeyId())
+
+	// Rewrite the file atomically
+	writeFile(t, path+".tmp", "[default]\napi_key_id = key_bravo_new\napi_secret = "+authn.GenerateBravoSecret()+"\n")
+	require.NoError(t, os.Rename(path+".tmp", path))
+
+	require.Eventually(t, func() bool {
+		return signer.ApiKeyId() == "key_bravo_new"
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -15,6 +15,9 @@ const (
 	PermissionDenied
 	NotFound
 	ResourceExhausted
+
+	// CircuitOpen means a call was not sent because a circuit breaker of a client is open
+	CircuitOpen
 )
 
 type Error struct {
@@ -39,6 +42,8 @@ func (e *Error) Error() string {
 		return fmt.Sprintf("not found: %s", e.Message)
 	case ResourceExhausted:
 		return fmt.Sprintf("resource exhausted: %s", e.Message)
+	case CircuitOpen:
+		return fmt.Sprintf("circuit open: %s", e.Message)
 	default:
 		return fmt.Sprintf("internal failure: %s", e.Message)
 	}
@@ -0,0 +1,25 @@
+package evrblk
+
+import (
+	"time"
+)
+
+// HedgingPolicy defines how generated clients hedge idempotent reads (Get* and List* methods): if a call takes longer
+// than a delay, a second signed attempt is sent, the first successful response is taken and the other attempt is
+// canceled. Hedging trades extra load on servers for lower tail latency.
+type HedgingPolicy struct {
+	// Delay before a hedged attempt. It is also used while not enough attempts of a method have been observed to
+	// learn a percentile. Zero disables hedging until then.
+	Delay time.Duration
+
+	// Percentile (between 0 and 1, e.g. 0.95) of observed durations of single attempts of a method, learned from
+	// evrblk_client_attempt_duration_seconds histogram after 100 attempts, is used as a delay instead of a fixed one
+	Percentile float64
+}
+
+// WithHedging enables hedging of idempotent reads with a given policy
+func WithHedging(policy HedgingPolicy) ClientOption {
+	return func(c *ClientConfig) {
+		c.Hedging = &policy
+	}
+}
@@ -1539,6 +1539,7 @@ func (x *NewBravoKey) GetSecret() string {
 
 type NewCharlieKey struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secret        string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1573,6 +1574,13 @@ func (*NewCharlieKey) Descriptor() ([]byte, []int) {
 	return file_proto_iam_preview_api_proto_rawDescGZIP(), []int{28}
 }
 
+func (x *NewCharlieKey) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
 type GetApiKeyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ApiKeyId      string                 `protobuf:"bytes,1,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`
@@ -2227,8 +2235,9 @@ const file_proto_iam_preview_api_proto_rawDesc = "" +
 	"\n" +
 	"public_pem\x18\x01 \x01(\tR\tpublicPem\"%\n" +
 	"\vNewBravoKey\x12\x16\n" +
-	"\x06secret\x18\x01 \x01(\tR\x06secret\"\x0f\n" +
-	"\rNewCharlieKey\"0\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\"'\n" +
+	"\rNewCharlieKey\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\"0\n" +
 	"\x10GetApiKeyRequest\x12\x1c\n" +
 	"\n" +
 	"api_key_id\x18\x01 \x01(\tR\bapiKeyId\"L\n" +
@@ -7,9 +7,6 @@ import (
 	evrblk "github.com/evrblk/evrblk-go"
 	internal "github.com/evrblk/evrblk-go/internal"
 	grpc "google.golang.org/grpc"
-	insecure "google.golang.org/grpc/credentials/insecure"
-	"log"
-	"time"
 )
 
 type IAMApi interface {
@@ -32,12 +29,14 @@ type IAMGrpcClient struct {
 	grpc   IamPreviewApiClient
 	conn   *grpc.ClientConn
 	signer evrblk.RequestSigner
+	config *evrblk.ClientConfig
 }
 
 var _ IAMApi = &IAMGrpcClient{}
 
 func (c *IAMGrpcClient) WithSigner(signer evrblk.RequestSigner) *IAMGrpcClient {
 	return &IAMGrpcClient{
+		config: c.config,
 		conn:   c.conn,
 		grpc:   c.grpc,
 		signer: signer,
@@ -49,251 +48,71 @@ func (c *IAMGrpcClient) Close() {
 }
 
 func (c *IAMGrpcClient) CreateRole(ctx context.Context, request *CreateRoleRequest) (*CreateRoleResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "CreateRole").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "CreateRole"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "CreateRole")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.CreateRole(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "CreateRole", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "CreateRole", request, false, evrblk.ControlPlaneMethod, c.grpc.CreateRole)
 }
 
 func (c *IAMGrpcClient) GetRole(ctx context.Context, request *GetRoleRequest) (*GetRoleResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "GetRole").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "GetRole"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "GetRole")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.GetRole(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "GetRole", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "GetRole", request, true, evrblk.ControlPlaneMethod, c.grpc.GetRole)
 }
 
 func (c *IAMGrpcClient) UpdateRole(ctx context.Context, request *UpdateRoleRequest) (*UpdateRoleResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "UpdateRole").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "UpdateRole"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "UpdateRole")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.UpdateRole(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "UpdateRole", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "UpdateRole", request, false, evrblk.ControlPlaneMethod, c.grpc.UpdateRole)
 }
 
 func (c *IAMGrpcClient) ListRoles(ctx context.Context, request *ListRolesRequest) (*ListRolesResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "ListRoles").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "ListRoles"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "ListRoles")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.ListRoles(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "ListRoles", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "ListRoles", request, true, evrblk.ControlPlaneMethod, c.grpc.ListRoles)
 }
 
 func (c *IAMGrpcClient) DeleteRole(ctx context.Context, request *DeleteRoleRequest) (*DeleteRoleResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "DeleteRole").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "DeleteRole"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "DeleteRole")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.DeleteRole(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "DeleteRole", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "DeleteRole", request, false, evrblk.ControlPlaneMethod, c.grpc.DeleteRole)
 }
 
 func (c *IAMGrpcClient) CreateUser(ctx context.Context, request *CreateUserRequest) (*CreateUserResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "CreateUser").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "CreateUser"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "CreateUser")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.CreateUser(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "CreateUser", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "CreateUser", request, false, evrblk.ControlPlaneMethod, c.grpc.CreateUser)
 }
 
 func (c *IAMGrpcClient) GetUser(ctx context.Context, request *GetUserRequest) (*GetUserResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "GetUser").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "GetUser"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "GetUser")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.GetUser(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "GetUser", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "GetUser", request, true, evrblk.ControlPlaneMethod, c.grpc.GetUser)
 }
 
 func (c *IAMGrpcClient) UpdateUser(ctx context.Context, request *UpdateUserRequest) (*UpdateUserResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "UpdateUser").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "UpdateUser"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "UpdateUser")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.UpdateUser(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "UpdateUser", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "UpdateUser", request, false, evrblk.ControlPlaneMethod, c.grpc.UpdateUser)
 }
 
 func (c *IAMGrpcClient) ListUsers(ctx context.Context, request *ListUsersRequest) (*ListUsersResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "ListUsers").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "ListUsers"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "ListUsers")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.ListUsers(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "ListUsers", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "ListUsers", request, true, evrblk.ControlPlaneMethod, c.grpc.ListUsers)
 }
 
 func (c *IAMGrpcClient) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*DeleteUserResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "DeleteUser").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "DeleteUser"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "DeleteUser")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.DeleteUser(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "DeleteUser", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "DeleteUser", request, false, evrblk.ControlPlaneMethod, c.grpc.DeleteUser)
 }
 
 func (c *IAMGrpcClient) CreateApiKey(ctx context.Context, request *CreateApiKeyRequest) (*CreateApiKeyResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "CreateApiKey").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "CreateApiKey"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "CreateApiKey")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.CreateApiKey(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "CreateApiKey", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "CreateApiKey", request, false, evrblk.ControlPlaneMethod, c.grpc.CreateApiKey)
 }
 
 func (c *IAMGrpcClient) GetApiKey(ctx context.Context, request *GetApiKeyRequest) (*GetApiKeyResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "GetApiKey").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "GetApiKey"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "GetApiKey")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.GetApiKey(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "GetApiKey", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "GetApiKey", request, true, evrblk.ControlPlaneMethod, c.grpc.GetApiKey)
 }
 
 func (c *IAMGrpcClient) ListApiKeys(ctx context.Context, request *ListApiKeysRequest) (*ListApiKeysResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "ListApiKeys").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "ListApiKeys"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "ListApiKeys")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.ListApiKeys(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "ListApiKeys", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "ListApiKeys", request, true, evrblk.ControlPlaneMethod, c.grpc.ListApiKeys)
 }
 
 func (c *IAMGrpcClient) DeleteApiKey(ctx context.Context, request *DeleteApiKeyRequest) (*DeleteApiKeyResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("IAM", "DeleteApiKey").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("IAM", "DeleteApiKey"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "IAM", "DeleteApiKey")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.DeleteApiKey(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("IAM", "DeleteApiKey", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "IAM", "DeleteApiKey", request, false, evrblk.ControlPlaneMethod, c.grpc.DeleteApiKey)
 }
 
-func NewIAMGrpcClient(address string, signer evrblk.RequestSigner) *IAMGrpcClient {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewIAMGrpcClient(address string, signer evrblk.RequestSigner, opts ...evrblk.ClientOption) (*IAMGrpcClient, error) {
+	config := evrblk.NewClientConfig(opts...)
+	conn, err := internal.Dial(address, config)
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return nil, err
 	}
 	return &IAMGrpcClient{
+		config: config,
 		conn:   conn,
 		grpc:   NewIamPreviewApiClient(conn),
 		signer: signer,
-	}
+	}, nil
 }
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"crypto/tls"
+
+	evrblk "github.com/evrblk/evrblk-go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Dial creates a gRPC connection of a generated client. No connection is made until the first call.
+func Dial(address string, config *evrblk.ClientConfig) (*grpc.ClientConn, error) {
+	return grpc.NewClient(address, DialOptions(config)...)
+}
+
+// DialOptions converts client configuration to gRPC dial options
+func DialOptions(config *evrblk.ClientConfig) []grpc.DialOption {
+	var opts []grpc.DialOption
+
+	if config.Insecure {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsConfig := config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+
+	if config.UserAgent != "" {
+		opts = append(opts, grpc.WithUserAgent(config.UserAgent))
+	}
+	if len(config.UnaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(config.UnaryInterceptors...))
+	}
+	if len(config.StreamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(config.StreamInterceptors...))
+	}
+
+	return append(opts, config.DialOptions...)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	evrblk "github.com/evrblk/evrblk-go"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -11,9 +12,14 @@ func ErrorFromRpcError(err error) error {
 	if st, ok := status.FromError(err); ok {
 		details := make(map[string]string)
 
-		//for _, d := range st.Details() {
-		//	d
-		//}
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.ErrorInfo); ok {
+				details["reason"] = info.Reason
+				for k, v := range info.Metadata {
+					details[k] = v
+				}
+			}
+		}
 
 		switch st.Code() {
 		case codes.OK:
@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// minHedgingSamples is a number of observed attempts of a method before a percentile of their durations is used
+	minHedgingSamples = 100
+
+	// hedgingDelayTTL is how long a learned delay is used before it is computed again from the histogram
+	hedgingDelayTTL = time.Second * 10
+)
+
+// errHedgeCanceled is a cause of canceling the other attempt of a hedged call when one of them has returned
+var errHedgeCanceled = errors.New("hedged attempt canceled")
+
+type learnedDelayKey struct {
+	service    string
+	method     string
+	percentile float64
+}
+
+type learnedDelay struct {
+	delay     time.Duration
+	ok        bool
+	learnedAt time.Time
+}
+
+var (
+	learnedDelaysMu sync.Mutex
+	learnedDelays   = make(map[learnedDelayKey]learnedDelay)
+)
+
+// HedgingDelay returns a delay before a hedged attempt of a call: a percentile of observed durations of attempts of a
+// method if a policy sets it and enough attempts have been observed, or a fixed delay of the policy otherwise
+func HedgingDelay(policy *evrblk.HedgingPolicy, service string, method string) time.Duration {
+	if policy.Percentile <= 0 {
+		return policy.Delay
+	}
+
+	key := learnedDelayKey{service: service, method: method, percentile: policy.Percentile}
+
+	learnedDelaysMu.Lock()
+	defer learnedDelaysMu.Unlock()
+
+	learned, found := learnedDelays[key]
+	if !found || time.Since(learned.learnedAt) > hedgingDelayTTL {
+		learned.delay, learned.ok = observedPercentile(service, method, policy.Percentile)
+		learned.learnedAt = time.Now()
+		learnedDelays[key] = learned
+	}
+
+	if !learned.ok {
+		return policy.Delay
+	}
+	return learned.delay
+}
+
+// observedPercentile estimates a percentile of durations of attempts of a method from AttemptsDuration native
+// histogram, as an upper bound of a bucket where it falls. Durations of whole calls would include retries, backoff and
+// waiting for the rate limiter, and overstate how long a single attempt takes.
+func observedPercentile(service string, method string, percentile float64) (time.Duration, bool) {
+	metric, ok := AttemptsDuration.WithLabelValues(service, method).(prometheus.Metric)
+	if !ok {
+		return 0, false
+	}
+
+	m := &dto.Metric{}
+	if err := metric.Write(m); err != nil {
+		return 0, false
+	}
+
+	h := m.GetHistogram()
+	if h.GetSampleCount() < minHedgingSamples {
+		return 0, false
+	}
+
+	rank := min(percentile, 1) * float64(h.GetSampleCount())
+	cumulative := float64(h.GetZeroCount())
+	if cumulative >= rank {
+		return secondsToDuration(h.GetZeroThreshold()), true
+	}
+
+	// Upper bound of bucket i is base^i, spans are runs of buckets separated by gaps, counts are delta-encoded
+	base := math.Pow(2, math.Pow(2, -float64(h.GetSchema())))
+	deltas := h.GetPositiveDelta()
+	var index int32
+	var count int64
+	var next int
+	for _, span := range h.GetPositiveSpan() {
+		index += span.GetOffset()
+		for i := uint32(0); i < span.GetLength() && next < len(deltas); i++ {
+			count += deltas[next]
+			next++
+			cumulative += float64(count)
+			if cumulative >= rank {
+				return secondsToDuration(math.Pow(base, float64(index))), true
+			}
+			index++
+		}
+	}
+
+	return 0, false
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// invokeHedged makes an attempt of a call, and if it takes longer than a delay, makes a second one. The first
+// successful response is returned and the other attempt is canceled. If both fail, the error of the last one is
+// returned. If the first attempt fails before the delay, no hedged attempt is made.
+func invokeHedged[Req any, Res any](ctx context.Context, config *evrblk.ClientConfig, delay time.Duration, signer evrblk.RequestSigner, service string, method string, message proto.Message, request *Req, call func(context.Context, *Req, ...grpc.CallOption) (*Res, error)) (*Res, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(errHedgeCanceled)
+
+	type result struct {
+		resp *Res
+		err  error
+	}
+
+	// Buffered for both attempts, so a canceled one does not block
+	results := make(chan result, 2)
+	attempt := func(hedged bool) {
+		if hedged && config.RateLimiter != nil {
+			if err := config.RateLimiter.Wait(ctx, service, method, message); err != nil {
+				results <- result{err: status.FromContextError(err).Err()}
+				return
+			}
+		}
+		resp, err := invokeOnce(ctx, config.WaitForReady, signer, service, method, message, request, call)
+		results <- result{resp: resp, err: err}
+	}
+
+	go attempt(false)
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedge := timer.C
+
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			HedgedRequestsCounter.WithLabelValues(service, method).Inc()
+			go attempt(true)
+			pending++
+
+		case r := <-results:
+			pending--
+			if r.err == nil || pending == 0 {
+				return r.resp, r.err
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Invoke makes a unary call with a given func of a gRPC client: applies a default timeout of a method category if the
+// context has no deadline, checks the circuit breaker of a client, waits for the rate limiter, signs a request, hedges
+// idempotent reads and retries the call according to the retry policy of a client if it is idempotent, converts errors
+// and records metrics
+func Invoke[Req any, Res any](ctx context.Context, config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, request *Req, idempotent bool, category evrblk.MethodCategory, call func(context.Context, *Req, ...grpc.CallOption) (*Res, error)) (*Res, error) {
+	TotalRequestsCounter.WithLabelValues(service, method).Inc()
+	start := time.Now()
+	defer MeasureSince(RequestsDuration.WithLabelValues(service, method), start)
+
+	if _, ok := ctx.Deadline(); !ok {
+		if timeout := config.Timeouts.Timeout(category); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+
+	message, ok := any(request).(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("request of %s.%s is not a proto message", service, method)
+	}
+
+	// Another attempt of a single-use call would be rejected as already used
+	if singleUse(signer) {
+		idempotent = false
+	}
+
+	policy := &config.RetryPolicy
+	class := MethodClass(method)
+
+	var hedgingDelay time.Duration
+	if config.Hedging != nil && idempotent && class == "read" {
+		hedgingDelay = HedgingDelay(config.Hedging, service, method)
+	}
+
+	var resp *Res
+	var err error
+	var generation evrblk.CircuitGeneration
+	for attempt := 1; ; attempt++ {
+		if config.CircuitBreaker != nil {
+			generation, err = allowCircuit(config.CircuitBreaker, service, method, class)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if config.RateLimiter != nil {
+			err = config.RateLimiter.Wait(ctx, service, method, message)
+			if err != nil {
+				if config.CircuitBreaker != nil {
+					config.CircuitBreaker.Cancel(service, class, generation)
+				}
+				err = status.FromContextError(err).Err()
+				break
+			}
+		}
+
+		if hedgingDelay > 0 {
+			resp, err = invokeHedged(ctx, config, hedgingDelay, signer, service, method, message, request, call)
+		} else {
+			resp, err = invokeOnce(ctx, config.WaitForReady, signer, service, method, message, request, call)
+		}
+		var signErr *signingError
+		if errors.As(err, &signErr) {
+			// The call was not sent, it says nothing about an endpoint and is not retried
+			if config.CircuitBreaker != nil {
+				config.CircuitBreaker.Cancel(service, class, generation)
+			}
+			FailedRequestsCounter.WithLabelValues(service, method, "signing").Inc()
+			return nil, signErr.err
+		}
+		if config.CircuitBreaker != nil {
+			recordCircuit(ctx, config.CircuitBreaker, service, class, generation, err)
+		}
+		if err == nil || !idempotent || attempt >= policy.MaxAttempts || !policy.Retryable(status.Code(err)) {
+			break
+		}
+
+		backoff := retryBackoff(policy, attempt, err)
+		if policy.Budget > 0 && time.Since(start)+backoff > policy.Budget {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			break
+		}
+
+		RetriesCounter.WithLabelValues(service, method, MetricLabelFromGrpcError(err)).Inc()
+		if !sleep(ctx, backoff) {
+			break
+		}
+	}
+
+	if err != nil {
+		FailedRequestsCounter.WithLabelValues(service, method, MetricLabelFromGrpcError(err)).Inc()
+	}
+
+	return resp, ErrorFromRpcError(err)
+}
+
+// invokeOnce makes a single attempt of a call. A signer observes the response and may ask to sign the request again
+// and repeat the call once (e.g. after correcting clock skew), since the server has rejected it without executing.
+func invokeOnce[Req any, Res any](ctx context.Context, waitForReady bool, signer evrblk.RequestSigner, service string, method string, message proto.Message, request *Req, call func(context.Context, *Req, ...grpc.CallOption) (*Res, error)) (*Res, error) {
+	signedCtx, err := signer.Sign(ctx, message, service, method)
+	if err != nil {
+		return nil, &signingError{err: err}
+	}
+
+	var header metadata.MD
+	start := time.Now()
+	resp, err := call(signedCtx, request, grpc.WaitForReady(waitForReady), grpc.Header(&header))
+	observeAttempt(ctx, service, method, start)
+	if ObserveResponse(signer, header, err) {
+		signedCtx, err = signer.Sign(ctx, message, service, method)
+		if err != nil {
+			return nil, &signingError{err: err}
+		}
+
+		start = time.Now()
+		resp, err = call(signedCtx, request, grpc.WaitForReady(waitForReady))
+		observeAttempt(ctx, service, method, start)
+	}
+
+	return resp, err
+}
+
+// observeAttempt records a duration of an attempt of a call, unless it is a losing hedged attempt canceled when the
+// other one has returned, which would understate durations. Attempts that exceeded a deadline are recorded at their
+// elapsed duration, since skipping slow attempts would bias percentiles low.
+func observeAttempt(ctx context.Context, service string, method string, start time.Time) {
+	if !errors.Is(context.Cause(ctx), errHedgeCanceled) {
+		MeasureSince(AttemptsDuration.WithLabelValues(service, method), start)
+	}
+}
+
+// signingError is a failure of a signer to sign a request. The request is not sent, so the error is returned as is
+// rather than converted like an error of a call.
+type signingError struct {
+	err error
+}
+
+func (e *signingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *signingError) Unwrap() error {
+	return e.err
+}
+
+// MethodClass groups methods of a service for circuit breakers: "read" for Get* and List* methods, "write" for all
+// others
+func MethodClass(method string) string {
+	if strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") {
+		return "read"
+	}
+	return "write"
+}
+
+// allowCircuit checks a circuit breaker before an attempt of a call and returns a generation of the circuit to record
+// the attempt in, or CircuitOpen error if the attempt is rejected
+func allowCircuit(breaker *evrblk.CircuitBreaker, service string, method string, class string) (evrblk.CircuitGeneration, error) {
+	state, generation, ok := breaker.Allow(service, class)
+	CircuitBreakerState.WithLabelValues(service, class).Set(float64(state))
+	if ok {
+		return generation, nil
+	}
+
+	FailedRequestsCounter.WithLabelValues(service, method, "circuit_open").Inc()
+	return generation, &evrblk.Error{
+		Message: fmt.Sprintf("circuit breaker of %s %s calls is %s", service, class, state),
+		Code:    evrblk.CircuitOpen,
+		Details: make(map[string]string),
+	}
+}
+
+// recordCircuit records an outcome of an attempt in a circuit breaker. Attempts canceled by a caller say nothing about
+// an endpoint and are not counted.
+func recordCircuit(ctx context.Context, breaker *evrblk.CircuitBreaker, service string, class string, generation evrblk.CircuitGeneration, err error) {
+	if err != nil && ctx.Err() == context.Canceled {
+		breaker.Cancel(service, class, generation)
+		return
+	}
+
+	state := breaker.Record(service, class, generation, ErrorFromRpcError(err))
+	CircuitBreakerState.WithLabelValues(service, class).Set(float64(state))
+}
+
+// retryBackoff picks a random backoff before a retry ("full jitter"), but not shorter than a delay asked by the server
+// in google.rpc.RetryInfo
+func retryBackoff(policy *evrblk.RetryPolicy, retry int, err error) time.Duration {
+	var backoff time.Duration
+	if upper := policy.Backoff(retry); upper > 0 {
+		backoff = time.Duration(rand.Int64N(int64(upper)))
+	}
+
+	if st, ok := status.FromError(err); ok {
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+				backoff = max(backoff, info.RetryDelay.AsDuration())
+			}
+		}
+	}
+
+	return backoff
+}
+
+// sleep waits for a given duration, returns false if the context is done earlier
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -24,12 +24,50 @@ var (
 		NativeHistogramMaxBucketNumber:  100,
 		NativeHistogramMinResetDuration: time.Hour,
 	}, []string{"service", "method"})
+	AttemptsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:                            "evrblk_client_attempt_duration_seconds",
+		Help:                            "Duration of a single attempt of a request, without retries and waiting",
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"service", "method"})
+	RetriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "evrblk_client_retries_total",
+		Help: "Number of retried attempts of requests",
+	}, []string{"service", "method", "error"})
+	HedgedRequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "evrblk_client_hedged_requests_total",
+		Help: "Number of hedged attempts of requests",
+	}, []string{"service", "method"})
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "evrblk_client_circuit_breaker_state",
+		Help: "State of circuit breakers: 0 closed, 1 open, 2 half-open",
+	}, []string{"service", "class"})
+	StreamMessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "evrblk_client_stream_messages_total",
+		Help: "Number of messages sent and received over streams",
+	}, []string{"service", "method", "direction"})
+	KeyRotationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "evrblk_client_key_rotations_total",
+		Help: "Number of API key reloads by rotating signers",
+	}, []string{"result"})
+	KeyFallbacksCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "evrblk_client_key_fallbacks_total",
+		Help: "Number of times rotating signers fell back to a previous API key",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(TotalRequestsCounter)
 	prometheus.MustRegister(FailedRequestsCounter)
 	prometheus.MustRegister(RequestsDuration)
+	prometheus.MustRegister(AttemptsDuration)
+	prometheus.MustRegister(RetriesCounter)
+	prometheus.MustRegister(HedgedRequestsCounter)
+	prometheus.MustRegister(CircuitBreakerState)
+	prometheus.MustRegister(StreamMessagesCounter)
+	prometheus.MustRegister(KeyRotationsCounter)
+	prometheus.MustRegister(KeyFallbacksCounter)
 }
 
 func MetricLabelFromGrpcError(err error) string {
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	evrblk "github.com/evrblk/evrblk-go"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ObserveResponse passes response header and error of a signed call to the signer if it implements
+// evrblk.ResponseObserver. Returns true if the call should be signed again and retried once.
+func ObserveResponse(signer evrblk.RequestSigner, header metadata.MD, err error) bool {
+	if o, ok := signer.(evrblk.ResponseObserver); ok {
+		return o.ObserveResponse(header, err)
+	}
+	return false
+}
+
+// singleUse checks whether calls signed by the signer are accepted only once (see evrblk.SingleUseSigner), so they
+// must not be retried or hedged
+func singleUse(signer evrblk.RequestSigner) bool {
+	s, ok := signer.(evrblk.SingleUseSigner)
+	return ok && s.SingleUse()
+}
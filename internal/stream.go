@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerStream signs a request of a server-streaming call the same way as a request of a unary call, and opens the
+// stream with a given func of a gRPC client. Like Invoke, it applies a default timeout of a method category if the
+// context has no deadline, checks the circuit breaker and waits for the rate limiter of a client.
+func ServerStream[Req any, Res any, S grpc.ClientStream](ctx context.Context, config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, request *Req, category evrblk.MethodCategory, open func(context.Context, *Req, ...grpc.CallOption) (S, error)) (grpc.ServerStreamingClient[Res], error) {
+	message, ok := any(request).(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("request of %s.%s is not a proto message", service, method)
+	}
+
+	s := newSignedClientStream(config, signer, service, method, true)
+
+	ctx, err := s.prepare(ctx, category, message)
+	if err != nil {
+		return nil, err
+	}
+
+	signedCtx, err := signer.Sign(ctx, message, service, method)
+	if err != nil {
+		return nil, s.abort(&signingError{err: err})
+	}
+
+	stream, err := open(signedCtx, request, grpc.WaitForReady(config.WaitForReady))
+	if err != nil {
+		return nil, s.finish(err)
+	}
+	s.start(stream)
+
+	return &grpc.GenericClientStream[Req, Res]{ClientStream: s}, nil
+}
+
+// ClientStream signs opening of a client-streaming call and opens the stream with a given func of a gRPC client, the
+// same way as ServerStream. Messages sent over the stream are signed with chained signatures, the signer must
+// implement evrblk.StreamSigner.
+func ClientStream[Req any, Res any, S grpc.ClientStream](ctx context.Context, config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, category evrblk.MethodCategory, open func(context.Context, ...grpc.CallOption) (S, error)) (grpc.ClientStreamingClient[Req, Res], error) {
+	s, err := openSignedStream(ctx, config, signer, service, method, false, category, open)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[Req, Res]{ClientStream: s}, nil
+}
+
+// BidiStream signs opening of a bidi-streaming call and opens the stream with a given func of a gRPC client, the same
+// way as ServerStream. Messages sent over the stream are signed with chained signatures, the signer must implement
+// evrblk.StreamSigner.
+func BidiStream[Req any, Res any, S grpc.ClientStream](ctx context.Context, config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, category evrblk.MethodCategory, open func(context.Context, ...grpc.CallOption) (S, error)) (grpc.BidiStreamingClient[Req, Res], error) {
+	s, err := openSignedStream(ctx, config, signer, service, method, true, category, open)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[Req, Res]{ClientStream: s}, nil
+}
+
+func openSignedStream[S grpc.ClientStream](ctx context.Context, config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, serverStream bool, category evrblk.MethodCategory, open func(context.Context, ...grpc.CallOption) (S, error)) (*signedClientStream, error) {
+	streamSigner, ok := signer.(evrblk.StreamSigner)
+	if !ok {
+		return nil, errors.New("request signer does not support streams")
+	}
+
+	s := newSignedClientStream(config, signer, service, method, serverStream)
+
+	// Messages are not known yet, the opening is throttled as a call without a request
+	ctx, err := s.prepare(ctx, category, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	signedCtx, messageSigner, err := streamSigner.SignStream(ctx, service, method)
+	if err != nil {
+		return nil, s.abort(&signingError{err: err})
+	}
+	s.messageSigner = messageSigner
+
+	stream, err := open(signedCtx, grpc.WaitForReady(config.WaitForReady))
+	if err != nil {
+		return nil, s.finish(err)
+	}
+	s.start(stream)
+
+	return s, nil
+}
+
+// signedClientStream signs sent messages (if messageSigner is set), converts errors and records metrics of a stream.
+// Unlike unary calls, streams are not retried, but a signer still observes how a stream has ended (e.g. to correct
+// clock skew for next calls). A stream is recorded in the circuit breaker of a client once it has ended.
+type signedClientStream struct {
+	grpc.ClientStream
+
+	config        *evrblk.ClientConfig
+	signer        evrblk.RequestSigner
+	messageSigner evrblk.MessageSigner
+	service       string
+	method        string
+	class         string
+
+	// serverStream is true if a server sends a stream of messages terminated by io.EOF, otherwise a single response
+	// ends the call
+	serverStream bool
+
+	// ctx is a context of the stream with a default timeout applied, it is canceled once the stream has ended
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// allowed is true if the circuit breaker has let the stream through, and its outcome must be recorded in the
+	// generation of the circuit it was allowed in
+	allowed    bool
+	generation evrblk.CircuitGeneration
+
+	started  time.Time
+	finished sync.Once
+}
+
+func newSignedClientStream(config *evrblk.ClientConfig, signer evrblk.RequestSigner, service string, method string, serverStream bool) *signedClientStream {
+	TotalRequestsCounter.WithLabelValues(service, method).Inc()
+
+	return &signedClientStream{
+		config:       config,
+		signer:       signer,
+		service:      service,
+		method:       method,
+		class:        MethodClass(method),
+		serverStream: serverStream,
+		started:      time.Now(),
+	}
+}
+
+// prepare applies a default timeout of a method category if the context has no deadline, checks the circuit breaker
+// and waits for the rate limiter of a client before a stream is opened
+func (s *signedClientStream) prepare(ctx context.Context, category evrblk.MethodCategory, message proto.Message) (context.Context, error) {
+	var timeout time.Duration
+	if _, ok := ctx.Deadline(); !ok {
+		timeout = s.config.Timeouts.Timeout(category)
+	}
+	if timeout > 0 {
+		s.ctx, s.cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		s.ctx, s.cancel = context.WithCancel(ctx)
+	}
+
+	if s.config.CircuitBreaker != nil {
+		generation, err := allowCircuit(s.config.CircuitBreaker, s.service, s.method, s.class)
+		if err != nil {
+			// Already counted as failed
+			s.finished.Do(func() {
+				MeasureSince(RequestsDuration.WithLabelValues(s.service, s.method), s.started)
+				s.cancel()
+			})
+			return nil, err
+		}
+		s.allowed = true
+		s.generation = generation
+	}
+
+	if s.config.RateLimiter != nil {
+		err := s.config.RateLimiter.Wait(s.ctx, s.service, s.method, message)
+		if err != nil {
+			return nil, s.abort(status.FromContextError(err).Err())
+		}
+	}
+
+	return s.ctx, nil
+}
+
+// start sets an opened gRPC stream. Streams abandoned by a caller still end when their context is done.
+func (s *signedClientStream) start(stream grpc.ClientStream) {
+	s.ClientStream = stream
+	context.AfterFunc(s.ctx, func() {
+		s.finish(status.FromContextError(s.ctx.Err()).Err())
+	})
+}
+
+// abort finishes a stream which has failed before it was sent, e.g. while waiting for the rate limiter or signing.
+// Such a stream says nothing about an endpoint and is not recorded in the circuit breaker.
+func (s *signedClientStream) abort(err error) error {
+	if s.allowed {
+		s.config.CircuitBreaker.Cancel(s.service, s.class, s.generation)
+		s.allowed = false
+	}
+	return s.finish(err)
+}
+
+func (s *signedClientStream) SendMsg(m any) error {
+	if s.messageSigner != nil {
+		message, ok := m.(proto.Message)
+		if !ok {
+			return fmt.Errorf("request of %s.%s is not a proto message", s.service, s.method)
+		}
+
+		signed, err := s.messageSigner.SignMessage(message)
+		if err != nil {
+			return err
+		}
+		m = signed
+	}
+
+	err := s.ClientStream.SendMsg(m)
+	if errors.Is(err, io.EOF) {
+		// The stream is terminated, its status is returned by RecvMsg
+		return err
+	} else if err != nil {
+		return s.finish(err)
+	}
+
+	StreamMessagesCounter.WithLabelValues(s.service, s.method, "sent").Inc()
+	return nil
+}
+
+func (s *signedClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if errors.Is(err, io.EOF) {
+		s.finish(nil)
+		return err
+	} else if err != nil {
+		return s.finish(err)
+	}
+
+	StreamMessagesCounter.WithLabelValues(s.service, s.method, "received").Inc()
+	if !s.serverStream {
+		s.finish(nil)
+	}
+	return nil
+}
+
+// finish records metrics of a stream once it has ended and converts its error
+func (s *signedClientStream) finish(err error) error {
+	var signErr *signingError
+	signFailed := errors.As(err, &signErr)
+
+	s.finished.Do(func() {
+		MeasureSince(RequestsDuration.WithLabelValues(s.service, s.method), s.started)
+
+		if s.allowed {
+			recordCircuit(s.ctx, s.config.CircuitBreaker, s.service, s.class, s.generation, err)
+		}
+		s.cancel()
+
+		if signFailed {
+			FailedRequestsCounter.WithLabelValues(s.service, s.method, "signing").Inc()
+		} else if err != nil {
+			FailedRequestsCounter.WithLabelValues(s.service, s.method, MetricLabelFromGrpcError(err)).Inc()
+
+			var header metadata.MD
+			if s.ClientStream != nil {
+				header, _ = s.ClientStream.Header()
+			}
+			ObserveResponse(s.signer, header, err)
+		}
+	})
+
+	if signFailed {
+		return signErr.err
+	}
+	return ErrorFromRpcError(err)
+}
@@ -1,13 +1,16 @@
 package test
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	evrblk "github.com/evrblk/evrblk-go"
 	"github.com/evrblk/evrblk-go/authn"
 	moab "github.com/evrblk/evrblk-go/moab/preview"
 	"github.com/labstack/gommon/random"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
 )
 
 // TestBravoSignAndVerify tests Bravo signing mechanism on a random timestamp, request and secret. A signature should be
@@ -126,3 +129,66 @@ func TestBravoConsistent(t *testing.T) {
 	err = authn.VerifyBravoSignature(signature, timestamp, now, hashedSecret, request, "Jakal", "CreateQueue")
 	require.Error(t, err)
 }
+
+// TestBravoAroundMidnight tests that a request signed right before midnight is verified right after midnight with keys
+// derived for adjacent dates
+func TestBravoAroundMidnight(t *testing.T) {
+	signedAt := time.Date(2024, 12, 3, 23, 59, 50, 0, time.UTC)
+	now := time.Date(2024, 12, 4, 0, 0, 20, 0, time.UTC)
+	secret := authn.GenerateBravoSecret()
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	signer, err := evrblk.NewBravoRequestSigner("key_bravo_test", secret, evrblk.WithClock(evrblk.ClockFunc(func() time.Time {
+		return signedAt
+	})))
+	require.NoError(t, err)
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	md, _ := metadata.FromOutgoingContext(signedCtx)
+	signature := md.Get("evrblk-signature")[0]
+
+	// Verified with keys derived around server time
+	keys, err := authn.DeriveBravoKeys(secret, now)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	err = authn.VerifyBravoSignatureWithKeys(signature, signedAt.Unix(), now, keys, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	// Not verified with a key of the current server date
+	hashedSecret, err := authn.HashBravoSecretWithDate(secret, "2024-12-04")
+	require.NoError(t, err)
+	err = authn.VerifyBravoSignature(signature, signedAt.Unix(), now, hashedSecret, request, "Moab", "GetQueue")
+	require.Error(t, err)
+}
+
+// TestGetDateOfTimestamp tests that dates of Bravo and Charlie keys are UTC dates regardless of the local time zone
+func TestGetDateOfTimestamp(t *testing.T) {
+	// Evening of December 3rd west of UTC is already December 4th in UTC
+	timestamp := time.Date(2024, 12, 3, 17, 0, 0, 0, time.FixedZone("UTC-8", -8*60*60)).Unix()
+	require.Equal(t, "2024-12-04", authn.GetDateOfTimestamp(timestamp))
+
+	// Morning of December 5th east of UTC is still December 4th in UTC
+	timestamp = time.Date(2024, 12, 5, 5, 0, 0, 0, time.FixedZone("UTC+14", 14*60*60)).Unix()
+	require.Equal(t, "2024-12-04", authn.GetDateOfTimestamp(timestamp))
+}
+
+// TestNewBravoRequestSigner tests that Bravo request signer validates its secret on creation
+func TestNewBravoRequestSigner(t *testing.T) {
+	secret := authn.GenerateBravoSecret()
+
+	_, err := evrblk.NewBravoRequestSigner("key_bravo_test", secret)
+	require.NoError(t, err)
+
+	// Not a Bravo key
+	_, err = evrblk.NewBravoRequestSigner("key_alfa_test", secret)
+	require.Error(t, err)
+
+	// Not a Base64 secret
+	_, err = evrblk.NewBravoRequestSigner("key_bravo_test", "not a secret!")
+	require.Error(t, err)
+
+	// Empty secret
+	_, err = evrblk.NewBravoRequestSigner("key_bravo_test", "")
+	require.Error(t, err)
+}
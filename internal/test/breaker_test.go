@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	"github.com/evrblk/evrblk-go/internal"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testCircuitBreakerPolicy = evrblk.CircuitBreakerPolicy{
+	FailureThreshold: 3,
+	OpenTimeout:      time.Millisecond * 100,
+	HalfOpenCalls:    2,
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	b := evrblk.NewCircuitBreaker(testCircuitBreakerPolicy)
+	failure := &evrblk.Error{Code: evrblk.Timeout}
+
+	// Only consecutive failures open a circuit, other errors are successes
+	for i := 0; i < 2; i++ {
+		_, generation, ok := b.Allow("Moab", "read")
+		require.True(t, ok)
+		require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", generation, failure))
+	}
+	_, closed, ok := b.Allow("Moab", "read")
+	require.True(t, ok)
+	b.Record("Moab", "read", closed, &evrblk.Error{Code: evrblk.NotFound})
+	for i := 0; i < 2; i++ {
+		require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", closed, failure))
+	}
+	require.Equal(t, evrblk.CircuitStateOpen, b.Record("Moab", "read", closed, &evrblk.Error{Code: evrblk.InternalFailure}))
+
+	state, _, ok := b.Allow("Moab", "read")
+	require.False(t, ok)
+	require.Equal(t, evrblk.CircuitStateOpen, state)
+
+	// Other circuits are not affected
+	_, _, ok = b.Allow("Moab", "write")
+	require.True(t, ok)
+	require.Equal(t, evrblk.CircuitStateClosed, b.State("IAM", "read"))
+
+	// Trial calls after the open timeout, a failed one opens the circuit again
+	time.Sleep(testCircuitBreakerPolicy.OpenTimeout)
+	state, generation, ok := b.Allow("Moab", "read")
+	require.True(t, ok)
+	require.Equal(t, evrblk.CircuitStateHalfOpen, state)
+	require.Equal(t, evrblk.CircuitStateOpen, b.Record("Moab", "read", generation, failure))
+
+	// All trial calls must succeed to close the circuit, canceled ones are not counted
+	time.Sleep(testCircuitBreakerPolicy.OpenTimeout)
+	_, generation, ok = b.Allow("Moab", "read")
+	require.True(t, ok)
+	_, _, ok = b.Allow("Moab", "read")
+	require.True(t, ok)
+	_, _, ok = b.Allow("Moab", "read")
+	require.False(t, ok)
+	b.Cancel("Moab", "read", generation)
+	require.Equal(t, evrblk.CircuitStateHalfOpen, b.Record("Moab", "read", generation, nil))
+	_, _, ok = b.Allow("Moab", "read")
+	require.True(t, ok)
+	require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", generation, nil))
+}
+
+func TestCircuitBreakerStaleOutcomes(t *testing.T) {
+	b := evrblk.NewCircuitBreaker(evrblk.CircuitBreakerPolicy{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Millisecond * 100,
+		HalfOpenCalls:    1,
+	})
+	failure := &evrblk.Error{Code: evrblk.Timeout}
+
+	// Two calls are allowed while the circuit is closed, the first failure opens it
+	_, closed, ok := b.Allow("Moab", "read")
+	require.True(t, ok)
+	_, _, ok = b.Allow("Moab", "read")
+	require.True(t, ok)
+	require.Equal(t, evrblk.CircuitStateOpen, b.Record("Moab", "read", closed, failure))
+
+	// A trial call is allowed in half-open state
+	time.Sleep(time.Millisecond * 100)
+	state, halfOpen, ok := b.Allow("Moab", "read")
+	require.True(t, ok)
+	require.Equal(t, evrblk.CircuitStateHalfOpen, state)
+
+	// A late success of a call allowed while the circuit was closed does not close it, a late cancel does not free a
+	// trial slot
+	require.Equal(t, evrblk.CircuitStateHalfOpen, b.Record("Moab", "read", closed, nil))
+	b.Cancel("Moab", "read", closed)
+	_, _, ok = b.Allow("Moab", "read")
+	require.False(t, ok)
+
+	// Only the outcome of the trial call counts
+	require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", halfOpen, nil))
+
+	// A late failure of a trial call does not count against the closed circuit
+	require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", halfOpen, failure))
+	require.Equal(t, evrblk.CircuitStateClosed, b.Record("Moab", "read", closed, failure))
+}
+
+func gaugeValue(t *testing.T, service string, class string) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, internal.CircuitBreakerState.WithLabelValues(service, class).Write(m))
+	return m.GetGauge().GetValue()
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {unavailable, unavailable, unavailable},
+	})
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(evrblk.NoRetries), evrblk.WithCircuitBreaker(testCircuitBreakerPolicy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+		require.Error(t, err)
+	}
+	require.Equal(t, float64(evrblk.CircuitStateOpen), gaugeValue(t, "Moab", "read"))
+
+	// Open circuit fails fast without sending a call
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.CircuitOpen, evrblkErr.Code)
+	require.Equal(t, 3, server.attempts("GetQueue"))
+
+	// Writes have their own circuit
+	_, err = client.CreateQueue(context.Background(), &moab.CreateQueueRequest{Name: "my_queue"})
+	require.NoError(t, err)
+
+	// Recovered endpoint closes the circuit
+	time.Sleep(testCircuitBreakerPolicy.OpenTimeout)
+	for i := 0; i < 2; i++ {
+		_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, float64(evrblk.CircuitStateClosed), gaugeValue(t, "Moab", "read"))
+	require.Equal(t, 5, server.attempts("GetQueue"))
+}
+
+func TestClientCircuitBreakerSigningError(t *testing.T) {
+	verifier, _ := newTestVerifier(t)
+	server, dialer := newFailingMoabServer(t, verifier, nil)
+
+	// Presigned signer can only sign another request
+	privatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	token, err := evrblk.Presign("key_alfa_test", privatePem, &moab.GetQueueRequest{QueueName: "other_queue"}, "Moab", "GetQueue")
+	require.NoError(t, err)
+	signer, err := evrblk.NewPresignedSigner(token)
+	require.NoError(t, err)
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signer, evrblk.WithInsecure(), evrblk.WithCircuitBreaker(testCircuitBreakerPolicy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	failures := counterValue(t, internal.FailedRequestsCounter.WithLabelValues("Moab", "GetQueue", "internal"))
+
+	// Local signing errors are returned as is, and neither open the circuit nor count as server failures
+	for i := 0; i < 5; i++ {
+		_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+		require.Error(t, err)
+		var evrblkErr *evrblk.Error
+		require.False(t, errors.As(err, &evrblkErr))
+	}
+	require.Equal(t, float64(evrblk.CircuitStateClosed), gaugeValue(t, "Moab", "read"))
+	require.Equal(t, failures, counterValue(t, internal.FailedRequestsCounter.WithLabelValues("Moab", "GetQueue", "internal")))
+	require.Equal(t, 0, server.attempts("GetQueue"))
+}
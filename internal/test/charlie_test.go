@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	iam "github.com/evrblk/evrblk-go/iam/preview"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/labstack/gommon/random"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestCharlieSignAndVerify tests Charlie signing mechanism on a random timestamp, request and secret. A signature
+// should be valid within 5 minutes time window and only for the service it was signed for.
+func TestCharlieSignAndVerify(t *testing.T) {
+	// Get current time
+	now := time.Now()
+	timestamp := now.Unix()
+
+	// Generate a new Charlie secret string
+	secret := authn.GenerateCharlieSecret()
+
+	// Build a random request
+	request := &moab.CreateQueueRequest{
+		Name:                      random.String(128, random.Alphanumeric),
+		Description:               random.String(128, random.Alphanumeric),
+		KeepaliveTimeoutInSeconds: 15,
+		RetryStrategy: &moab.RetryStrategy{
+			RetryIntervalsInSeconds: []int64{1, 2, 3, 4, 5},
+		},
+		ExpiresInSeconds: 86400,
+	}
+
+	// Sign the request
+	signature, err := authn.SignCharlie(timestamp, secret, request, "Moab", "CreateQueue")
+	require.NoError(t, err)
+
+	// Get a key derived for the date and the service
+	date := authn.GetDateOfTimestamp(timestamp)
+	hashedSecret, err := authn.HashCharlieSecret(secret, date, "Moab")
+	require.NoError(t, err)
+
+	// Check the signature within 5 minutes time window (timestamp = now)
+	err = authn.VerifyCharlieSignature(signature, timestamp, now, hashedSecret, request, "Moab", "CreateQueue")
+	require.NoError(t, err)
+
+	// Check the signature outside 5 minutes time window (timestamp = now + 6 minutes)
+	err = authn.VerifyCharlieSignature(signature, timestamp, now.Add(time.Minute*6), hashedSecret, request, "Moab", "CreateQueue")
+	require.Error(t, err)
+
+	// Check the signature for different method name
+	err = authn.VerifyCharlieSignature(signature, timestamp, now, hashedSecret, request, "Moab", "DeleteQueue")
+	require.Error(t, err)
+
+	// Check the signature with a key derived for another service
+	otherHashedSecret, err := authn.HashCharlieSecret(secret, date, "Grackle")
+	require.NoError(t, err)
+	err = authn.VerifyCharlieSignature(signature, timestamp, now, otherHashedSecret, request, "Moab", "CreateQueue")
+	require.Error(t, err)
+}
+
+// TestCharlieConsistent tests that Charlie signing mechanism produces the same signature for a given timestamp,
+// request, and secret and does not change over time (degradation test).
+func TestCharlieConsistent(t *testing.T) {
+	// Given timestamp
+	timestamp := int64(1733240571)
+	now := time.Unix(1733240571, 0)
+
+	// Given secret
+	secret := "3q2+7wABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhs="
+
+	// Given request
+	request := &moab.CreateQueueRequest{
+		Name:                      "my_queue_1",
+		Description:               "Some description",
+		KeepaliveTimeoutInSeconds: 15,
+		ExpiresInSeconds:          86400,
+	}
+
+	// Sign the request
+	signature, err := authn.SignCharlie(timestamp, secret, request, "Moab", "CreateQueue")
+	require.NoError(t, err)
+	// The same request, same timestamp, and same secret should always produce the same signature
+	require.Equal(t, "dekER8WfVedzFJ2tBt/YV0zCGNcpS1CKotiU9q6foIA=", signature)
+
+	// Check that this signature can be successfully verified
+	hashedSecret, err := authn.HashCharlieSecret(secret, authn.GetDateOfTimestamp(timestamp), "Moab")
+	require.NoError(t, err)
+	err = authn.VerifyCharlieSignature(signature, timestamp, now, hashedSecret, request, "Moab", "CreateQueue")
+	require.NoError(t, err)
+}
+
+// TestNewRequestSigner tests that a signer is chosen by API key ID prefix
+func TestNewRequestSigner(t *testing.T) {
+	_, err := evrblk.NewRequestSigner("key_charlie_test", authn.GenerateCharlieSecret())
+	require.NoError(t, err)
+
+	// Charlie secret is too short
+	_, err = evrblk.NewRequestSigner("key_charlie_test", "AAAA")
+	require.Error(t, err)
+
+	// Unknown key type is not guessed
+	_, err = evrblk.NewRequestSigner("key_zulu_test", authn.GenerateBravoSecret())
+	require.Error(t, err)
+}
+
+// TestCharlieSecretFromIam tests that a Charlie secret returned by IAM in a new API key survives the wire and can be
+// used to sign requests accepted by a verifier holding the same secret.
+func TestCharlieSecretFromIam(t *testing.T) {
+	secret := authn.GenerateCharlieSecret()
+	data, err := proto.Marshal(&iam.CreateApiKeyResponse{
+		ApiKey: &iam.NewApiKey{
+			Id:      "key_charlie_test",
+			KeyType: &iam.NewApiKey_Charlie{Charlie: &iam.NewCharlieKey{Secret: secret}},
+		},
+	})
+	require.NoError(t, err)
+
+	response := &iam.CreateApiKeyResponse{}
+	require.NoError(t, proto.Unmarshal(data, response))
+	require.Equal(t, secret, response.ApiKey.GetCharlie().GetSecret())
+
+	signer, err := evrblk.NewRequestSigner(response.ApiKey.Id, response.ApiKey.GetCharlie().GetSecret())
+	require.NoError(t, err)
+
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		charlieKeys: map[string]string{"key_charlie_test": secret},
+	}, map[string]string{moabFullServiceName: "Moab"})
+	interceptor := verifier.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + moabFullServiceName + "/GetQueue"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &moab.GetQueueResponse{}, nil
+	}
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	_, err = interceptor(incomingContext(t, signedCtx), request, info, handler)
+	require.NoError(t, err)
+}
@@ -0,0 +1,307 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// testMoabServer implements a few Moab methods. If intercept is set, it is called first, and its error is returned
+// instead of a response.
+type testMoabServer struct {
+	moab.UnimplementedMoabPreviewApiServer
+
+	intercept func(ctx context.Context, method string) error
+}
+
+func (s *testMoabServer) before(ctx context.Context, method string) error {
+	if s.intercept != nil {
+		return s.intercept(ctx, method)
+	}
+	return nil
+}
+
+func (s *testMoabServer) GetQueue(ctx context.Context, request *moab.GetQueueRequest) (*moab.GetQueueResponse, error) {
+	if err := s.before(ctx, "GetQueue"); err != nil {
+		return nil, err
+	}
+	return &moab.GetQueueResponse{Queue: &moab.Queue{Name: request.QueueName}}, nil
+}
+
+func (s *testMoabServer) CreateQueue(ctx context.Context, request *moab.CreateQueueRequest) (*moab.CreateQueueResponse, error) {
+	if err := s.before(ctx, "CreateQueue"); err != nil {
+		return nil, err
+	}
+	return &moab.CreateQueueResponse{Queue: &moab.Queue{Name: request.Name}}, nil
+}
+
+func (s *testMoabServer) Enqueue(ctx context.Context, request *moab.EnqueueRequest) (*moab.EnqueueResponse, error) {
+	if err := s.before(ctx, "Enqueue"); err != nil {
+		return nil, err
+	}
+	response := &moab.EnqueueResponse{}
+	for _, entry := range request.Entries {
+		response.Tasks = append(response.Tasks, &moab.Task{QueueName: request.QueueName, Payload: entry.Payload})
+	}
+	return response, nil
+}
+
+// newMoabServer starts an in-memory gRPC server with a Moab service. Clients connect to it with "passthrough:///bufnet"
+// address and bufconnDialer option.
+func newMoabServer(t *testing.T, server moab.MoabPreviewApiServer, opts ...grpc.ServerOption) *bufconn.Listener {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(opts...)
+	moab.RegisterMoabPreviewApiServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	return listener
+}
+
+func bufconnDialer(listener *bufconn.Listener) evrblk.ClientOption {
+	return evrblk.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+}
+
+// newServerTLSConfig creates a self-signed certificate for "bufnet" and returns server and client TLS configs
+func newServerTLSConfig(t *testing.T) (*tls.Config, *tls.Config) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bufnet"},
+		DNSNames:     []string{"bufnet"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(certificate)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{certificate}, PrivateKey: privateKey}},
+	}
+	clientConfig := &tls.Config{
+		RootCAs: roots,
+	}
+	return serverConfig, clientConfig
+}
+
+func TestClientOptions(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+
+	var userAgent string
+	listener := newMoabServer(t, &testMoabServer{}, grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			userAgent = strings.Join(md.Get("user-agent"), ",")
+			return handler(ctx, req)
+		},
+		verifier.UnaryServerInterceptor(),
+	))
+
+	var intercepted []string
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// Interceptors see signed calls
+		md, _ := metadata.FromOutgoingContext(ctx)
+		require.NotEmpty(t, md.Get("evrblk-signature"))
+		intercepted = append(intercepted, method)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0],
+		evrblk.WithInsecure(),
+		evrblk.WithUserAgent("my-app/1.0"),
+		evrblk.WithUnaryInterceptors(interceptor),
+		bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, "my_queue", resp.Queue.Name)
+	require.Equal(t, []string{"/com.evrblk.moab.preview.MoabPreviewApi/GetQueue"}, intercepted)
+	require.True(t, strings.HasPrefix(userAgent, "my-app/1.0 grpc-go/"), userAgent)
+
+	// Default user agent
+	client, err = moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(userAgent, "evrblk-go grpc-go/"), userAgent)
+
+	// Invalid address is an error, not a crash
+	_, err = moab.NewMoabGrpcClient("dns://%zz/bufnet", signers[0])
+	require.Error(t, err)
+}
+
+func TestClientTLS(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	serverConfig, clientConfig := newServerTLSConfig(t)
+	listener := newMoabServer(t, &testMoabServer{},
+		grpc.Creds(credentials.NewTLS(serverConfig)),
+		grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithTLSConfig(clientConfig), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, "my_queue", resp.Queue.Name)
+
+	// TLS with system roots by default, which do not trust the self-signed certificate
+	client, err = moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.GetQueue(ctx, &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.NotEqual(t, codes.OK, status.Code(err))
+}
+
+// testRateLimiter lets a given number of calls through and then fails as if the context deadline had come
+type testRateLimiter struct {
+	allowed int
+	calls   []string
+}
+
+func (l *testRateLimiter) Wait(ctx context.Context, service string, method string, request proto.Message) error {
+	l.calls = append(l.calls, service+"."+method)
+	if len(l.calls) > l.allowed {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestClientRateLimiter(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {status.Error(codes.Unavailable, "unavailable")},
+	})
+
+	limiter := &testRateLimiter{allowed: 3}
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(fastRetryPolicy), evrblk.WithRateLimiter(limiter), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Every attempt waits for the limiter
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Moab.GetQueue", "Moab.GetQueue"}, limiter.calls)
+	require.Equal(t, 2, server.attempts("GetQueue"))
+
+	_, err = client.Enqueue(context.Background(), &moab.EnqueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+
+	// Throttled calls are not sent
+	_, err = client.Enqueue(context.Background(), &moab.EnqueueRequest{QueueName: "my_queue"})
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.Timeout, evrblkErr.Code)
+	require.Equal(t, 1, server.attempts("Enqueue"))
+}
+
+func TestClientTimeouts(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+
+	// Server holds calls until they are canceled
+	listener := newMoabServer(t, &testMoabServer{intercept: func(ctx context.Context, method string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}, grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+
+	timeouts := evrblk.Timeouts{ControlPlane: time.Millisecond * 50, DataPlane: time.Millisecond * 200}
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithTimeouts(timeouts), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Default timeout of a method category
+	start := time.Now()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.Timeout, evrblkErr.Code)
+	require.InDelta(t, time.Millisecond*50, time.Since(start), float64(time.Millisecond*40))
+
+	start = time.Now()
+	_, err = client.Enqueue(context.Background(), &moab.EnqueueRequest{QueueName: "my_queue"})
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.Timeout, evrblkErr.Code)
+	require.InDelta(t, time.Millisecond*200, time.Since(start), float64(time.Millisecond*40))
+
+	// Deadline of a context takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*150)
+	defer cancel()
+	start = time.Now()
+	_, err = client.GetQueue(ctx, &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.Timeout, evrblkErr.Code)
+	require.InDelta(t, time.Millisecond*150, time.Since(start), float64(time.Millisecond*40))
+
+	// Default timeouts are opt-in
+	require.Equal(t, evrblk.NoTimeouts, evrblk.NewClientConfig().Timeouts)
+}
+
+func TestClientWaitForReady(t *testing.T) {
+	_, signers := newTestVerifier(t)
+
+	// Nothing listens
+	listener := bufconn.Listen(1024)
+	require.NoError(t, listener.Close())
+
+	timeouts := evrblk.Timeouts{ControlPlane: time.Millisecond * 100}
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithTimeouts(timeouts), evrblk.WithRetryPolicy(evrblk.NoRetries), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Waits for a connection until the timeout
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.Timeout, evrblkErr.Code)
+
+	client, err = moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithTimeouts(timeouts), evrblk.WithRetryPolicy(evrblk.NoRetries), evrblk.WithWaitForReady(false), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Fails right away
+	start := time.Now()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.InternalFailure, evrblkErr.Code)
+	require.Less(t, time.Since(start), time.Millisecond*50)
+}
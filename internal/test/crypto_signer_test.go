@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHsmSigner imitates a private key stored in an HSM: it only exposes a public key and signs digests
+type fakeHsmSigner struct {
+	privateKey crypto.Signer
+	raw        bool
+	calls      int
+}
+
+func (s *fakeHsmSigner) Public() crypto.PublicKey {
+	return s.privateKey.Public()
+}
+
+func (s *fakeHsmSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+
+	ecdsaKey, ok := s.privateKey.(*ecdsa.PrivateKey)
+	if !ok || !s.raw {
+		return s.privateKey.Sign(rand, digest, opts)
+	}
+
+	// Return raw r || s signature, like PKCS#11 tokens do
+	r, sig, err := ecdsa.Sign(rand, ecdsaKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	return append(r.FillBytes(make([]byte, 32)), sig.FillBytes(make([]byte, 32))...), nil
+}
+
+func publicPemOf(t *testing.T, publicKey crypto.PublicKey) string {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+}
+
+// TestAlfaRequestSignerWithKey tests that requests signed by an external crypto.Signer are verified with its public key
+func TestAlfaRequestSignerWithKey(t *testing.T) {
+	ecdsaKey, _, err := authn.GenerateP256KeyPair()
+	require.NoError(t, err)
+	ed25519Key, _, err := authn.GenerateEd25519KeyPair()
+	require.NoError(t, err)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	for _, hsm := range []*fakeHsmSigner{
+		{privateKey: ecdsaKey},
+		{privateKey: ecdsaKey, raw: true},
+		{privateKey: ed25519Key},
+	} {
+		signer, err := evrblk.NewAlfaRequestSignerWithKey("key_alfa_test", hsm)
+		require.NoError(t, err)
+
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		require.Equal(t, 1, hsm.calls)
+
+		verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+			alfaKeys: map[string]string{"key_alfa_test": publicPemOf(t, hsm.Public())},
+		}, map[string]string{moabFullServiceName: "Moab"})
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+	}
+
+	// Keys of unsupported types are rejected
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = evrblk.NewAlfaRequestSignerWithKey("key_alfa_test", &fakeHsmSigner{privateKey: rsaKey})
+	require.Error(t, err)
+
+	// In-memory keys are crypto.Signer too
+	_, err = evrblk.NewAlfaRequestSignerWithKey("key_alfa_test", ecdsaKey)
+	require.NoError(t, err)
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	"github.com/evrblk/evrblk-go/internal"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestClientHedging(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+
+	// The first GetQueue call hangs until it is canceled, CreateQueue calls are slow
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	canceled := make(chan struct{})
+	listener := newMoabServer(t, &testMoabServer{intercept: func(ctx context.Context, method string) error {
+		mu.Lock()
+		calls[method]++
+		call := calls[method]
+		mu.Unlock()
+
+		switch {
+		case method == "GetQueue" && call == 1:
+			<-ctx.Done()
+			close(canceled)
+			return ctx.Err()
+		case method == "CreateQueue":
+			time.Sleep(time.Millisecond * 100)
+		}
+		return nil
+	}}, grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithHedging(evrblk.HedgingPolicy{Delay: time.Millisecond * 20}), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	hedges := counterValue(t, internal.HedgedRequestsCounter.WithLabelValues("Moab", "GetQueue"))
+	attempts := histogram(t, internal.AttemptsDuration.WithLabelValues("Moab", "GetQueue")).GetSampleCount()
+
+	// Hedged attempt wins, the first one is canceled
+	start := time.Now()
+	resp, err := client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, "my_queue", resp.Queue.Name)
+	require.Less(t, time.Since(start), time.Millisecond*500)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("first attempt is not canceled")
+	}
+	require.Equal(t, hedges+1, counterValue(t, internal.HedgedRequestsCounter.WithLabelValues("Moab", "GetQueue")))
+
+	// Only the winning attempt is observed, the canceled one would understate durations
+	require.Never(t, func() bool {
+		return histogram(t, internal.AttemptsDuration.WithLabelValues("Moab", "GetQueue")).GetSampleCount() != attempts+1
+	}, time.Millisecond*100, time.Millisecond*10)
+
+	// Fast calls are not hedged
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, hedges+1, counterValue(t, internal.HedgedRequestsCounter.WithLabelValues("Moab", "GetQueue")))
+
+	// Writes are never hedged
+	_, err = client.CreateQueue(context.Background(), &moab.CreateQueueRequest{Name: "my_queue"})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 3, calls["GetQueue"])
+	require.Equal(t, 1, calls["CreateQueue"])
+}
+
+// TestClientHedgingReplayCache tests that a hedged attempt is not rejected as a replay of the first one by a server
+// with a replay cache, since each attempt is signed with its own nonce
+func TestClientHedgingReplayCache(t *testing.T) {
+	verifier, signers := newTestVerifier(t, evrblk.WithSignatureVersion2())
+	cache, err := authn.NewMemoryReplayCache(1000)
+	require.NoError(t, err)
+	verifier = verifier.WithReplayCache(cache)
+
+	// The first call hangs until it is canceled
+	var mu sync.Mutex
+	calls := 0
+	listener := newMoabServer(t, &testMoabServer{intercept: func(ctx context.Context, method string) error {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		if call == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}, grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+
+	for _, signer := range signers {
+		mu.Lock()
+		calls = 0
+		mu.Unlock()
+
+		client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signer, evrblk.WithInsecure(), evrblk.WithHedging(evrblk.HedgingPolicy{Delay: time.Millisecond * 20}), bufconnDialer(listener))
+		require.NoError(t, err)
+
+		// Hedged attempt is signed within the same second as the first one and reaches the handler
+		resp, err := client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+		require.NoError(t, err)
+		require.Equal(t, "my_queue", resp.Queue.Name)
+		client.Close()
+
+		mu.Lock()
+		require.Equal(t, 2, calls)
+		mu.Unlock()
+	}
+}
+
+// TestAttemptDeadlineObserved tests that an attempt that exceeded a deadline is observed at its elapsed duration, so
+// learned hedging delays are not biased low by skipping slow attempts
+func TestAttemptDeadlineObserved(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	listener := newMoabServer(t, &testMoabServer{intercept: func(ctx context.Context, method string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}, grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(evrblk.NoRetries), bufconnDialer(listener))
+	require.NoError(t, err)
+	defer client.Close()
+
+	observer := internal.AttemptsDuration.WithLabelValues("Moab", "GetQueue")
+	before := histogram(t, observer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err = client.GetQueue(ctx, &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+
+	after := histogram(t, observer)
+	require.Equal(t, before.GetSampleCount()+1, after.GetSampleCount())
+	require.GreaterOrEqual(t, after.GetSampleSum()-before.GetSampleSum(), 0.04)
+}
+
+func histogram(t *testing.T, observer prometheus.Observer) *dto.Histogram {
+	m := &dto.Metric{}
+	require.NoError(t, observer.(prometheus.Metric).Write(m))
+	return m.GetHistogram()
+}
+
+func TestHedgingDelay(t *testing.T) {
+	// Durations of whole calls (with retries and waiting) are not used
+	calls := internal.RequestsDuration.WithLabelValues("Test", "HedgingDelay")
+	for i := 0; i < 100; i++ {
+		calls.Observe(5)
+	}
+
+	fixed := &evrblk.HedgingPolicy{Delay: time.Millisecond * 50, Percentile: 0.5}
+	require.Equal(t, time.Millisecond*50, internal.HedgingDelay(fixed, "Test", "HedgingDelay"))
+
+	observer := internal.AttemptsDuration.WithLabelValues("Test", "HedgingDelay")
+	for i := 0; i < 95; i++ {
+		observer.Observe(0.01)
+	}
+	for i := 0; i < 5; i++ {
+		observer.Observe(1)
+	}
+
+	// Upper bound of a histogram bucket, within 10%
+	delay := internal.HedgingDelay(&evrblk.HedgingPolicy{Delay: time.Millisecond * 50, Percentile: 0.9}, "Test", "HedgingDelay")
+	require.GreaterOrEqual(t, delay, time.Millisecond*10)
+	require.LessOrEqual(t, delay, time.Millisecond*11)
+
+	delay = internal.HedgingDelay(&evrblk.HedgingPolicy{Percentile: 0.99}, "Test", "HedgingDelay")
+	require.GreaterOrEqual(t, delay, time.Second)
+	require.LessOrEqual(t, delay, time.Millisecond*1100)
+
+	// Other methods are not observed yet
+	require.Equal(t, time.Duration(0), internal.HedgingDelay(&evrblk.HedgingPolicy{Percentile: 0.9}, "Test", "Other"))
+}
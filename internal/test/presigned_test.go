@@ -0,0 +1,160 @@
+package test
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPresignedSigner(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	cache, err := authn.NewMemoryReplayCache(100)
+	require.NoError(t, err)
+	verifier = verifier.WithPresignedTokens(cache)
+	interceptor := verifier.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + moabFullServiceName + "/Enqueue"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &moab.EnqueueResponse{}, nil
+	}
+	request := &moab.EnqueueRequest{
+		QueueName: "uploads",
+		Entries:   []*moab.EnqueueRequestEntry{{Payload: []byte("payload")}},
+	}
+
+	for apiKeyId, secret := range keys {
+		token, err := evrblk.Presign(apiKeyId, secret, request, "Moab", "Enqueue")
+		require.NoError(t, err)
+
+		signer, err := evrblk.NewPresignedSigner(token)
+		require.NoError(t, err)
+
+		// Only the presigned call can be signed
+		_, err = signer.Sign(context.Background(), &moab.EnqueueRequest{QueueName: "other"}, "Moab", "Enqueue")
+		require.Error(t, err)
+
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "Enqueue")
+		require.NoError(t, err)
+
+		// Another request with the token
+		_, err = interceptor(incomingContext(t, signedCtx), &moab.EnqueueRequest{QueueName: "other"}, info, handler)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		// Valid token
+		_, err = interceptor(incomingContext(t, signedCtx), request, info, handler)
+		require.NoError(t, err)
+
+		// Token can be used once
+		_, err = interceptor(incomingContext(t, signedCtx), request, info, handler)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestPresignedTokenRejected(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	token, err := evrblk.Presign("key_alfa_test", keys["key_alfa_test"], request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("evrblk-presigned-token", token))
+
+	// Verifier which does not accept presigned tokens
+	err = verifier.Verify(ctx, request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Verifier without a replay cache fails closed
+	err = verifier.WithPresignedTokens(nil).Verify(ctx, request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	cache, err := authn.NewMemoryReplayCache(100)
+	require.NoError(t, err)
+	verifier = verifier.WithPresignedTokens(cache)
+	require.NoError(t, verifier.Verify(ctx, request, "Moab", "GetQueue"))
+
+	// Expired token
+	expired, err := evrblk.Presign("key_bravo_test", keys["key_bravo_test"], request, "Moab", "GetQueue",
+		evrblk.WithPresignTTL(time.Minute),
+		evrblk.WithPresignClock(evrblk.ClockFunc(func() time.Time { return time.Now().Add(-time.Hour) })))
+	require.NoError(t, err)
+	err = verifier.VerifyPresigned(context.Background(), expired, request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Token signed by an unknown key
+	otherPrivatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	forged, err := evrblk.Presign("key_alfa_test", otherPrivatePem, request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = verifier.VerifyPresigned(context.Background(), forged, request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Malformed token
+	err = verifier.VerifyPresigned(context.Background(), "token", request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestPresignedTokenMalleated(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	cache, err := authn.NewMemoryReplayCache(100)
+	require.NoError(t, err)
+	verifier = verifier.WithPresignedTokens(cache)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	token, err := evrblk.Presign("key_alfa_test", keys["key_alfa_test"], request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	require.NoError(t, verifier.VerifyPresigned(context.Background(), token, request, "Moab", "GetQueue"))
+
+	// Malleated ECDSA signature (r, n-s) is valid, but it is the same token
+	claims, signature, _ := strings.Cut(token, ".")
+	der, err := base64.RawURLEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	sig := authn.ECDSASignature{}
+	_, err = asn1.Unmarshal(der, &sig)
+	require.NoError(t, err)
+	sig.S.Sub(elliptic.P256().Params().N, sig.S)
+	der, err = asn1.Marshal(sig)
+	require.NoError(t, err)
+	malleated := claims + "." + base64.RawURLEncoding.EncodeToString(der)
+
+	err = verifier.VerifyPresigned(context.Background(), malleated, request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "already used")
+}
+
+func TestPresignedSignerNotRetried(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	cache, err := authn.NewMemoryReplayCache(100)
+	require.NoError(t, err)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier.WithPresignedTokens(cache), map[string][]error{
+		"GetQueue": {unavailable},
+	})
+
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+	token, err := evrblk.Presign("key_alfa_test", keys["key_alfa_test"], request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	signer, err := evrblk.NewPresignedSigner(token)
+	require.NoError(t, err)
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signer, evrblk.WithInsecure(), evrblk.WithRetryPolicy(evrblk.DefaultRetryPolicy), evrblk.WithHedging(evrblk.HedgingPolicy{Delay: time.Millisecond}), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// A retry would be rejected as already used, the server error is returned instead
+	_, err = client.GetQueue(context.Background(), request)
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.InternalFailure, evrblkErr.Code)
+	require.Equal(t, 1, server.attempts("GetQueue"))
+}
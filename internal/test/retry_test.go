@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/internal"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var fastRetryPolicy = evrblk.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond * 10,
+	Multiplier:     2,
+	RetryableCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+}
+
+// failingMoabServer fails first calls of every method with given errors and records signatures (or presigned tokens)
+// of all calls
+type failingMoabServer struct {
+	mu         sync.Mutex
+	errors     map[string][]error
+	signatures map[string][]string
+}
+
+func newFailingMoabServer(t *testing.T, verifier *evrblk.SignatureVerifier, errors map[string][]error) (*failingMoabServer, evrblk.ClientOption) {
+	s := &failingMoabServer{
+		errors:     errors,
+		signatures: make(map[string][]string),
+	}
+	listener := newMoabServer(t, &testMoabServer{intercept: s.intercept}, grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()))
+	return s, bufconnDialer(listener)
+}
+
+func (s *failingMoabServer) intercept(ctx context.Context, method string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.signatures[method] = append(s.signatures[method], md.Get("evrblk-signature")...)
+	s.signatures[method] = append(s.signatures[method], md.Get("evrblk-presigned-token")...)
+
+	if len(s.errors[method]) > 0 {
+		err := s.errors[method][0]
+		s.errors[method] = s.errors[method][1:]
+		return err
+	}
+	return nil
+}
+
+func (s *failingMoabServer) attempts(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.signatures[method])
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, counter.Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {unavailable, status.Error(codes.ResourceExhausted, "slow down")},
+	})
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(fastRetryPolicy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	retries := counterValue(t, internal.RetriesCounter.WithLabelValues("Moab", "GetQueue", "internal"))
+
+	resp, err := client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.Equal(t, "my_queue", resp.Queue.Name)
+	require.Equal(t, 3, server.attempts("GetQueue"))
+	require.Equal(t, retries+1, counterValue(t, internal.RetriesCounter.WithLabelValues("Moab", "GetQueue", "internal")))
+
+	// Retries stop after MaxAttempts
+	server.errors["GetQueue"] = []error{unavailable, unavailable, unavailable, unavailable}
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	var evrblkErr *evrblk.Error
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.InternalFailure, evrblkErr.Code)
+	require.Equal(t, 6, server.attempts("GetQueue"))
+
+	// Errors which are not retryable
+	server.errors["GetQueue"] = []error{status.Error(codes.InvalidArgument, "invalid"), unavailable}
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.ErrorAs(t, err, &evrblkErr)
+	require.Equal(t, evrblk.InvalidRequest, evrblkErr.Code)
+	require.Equal(t, 7, server.attempts("GetQueue"))
+}
+
+func TestRetryOptIn(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {unavailable, unavailable},
+	})
+
+	// Even idempotent calls are not retried without a retry policy
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.Equal(t, 1, server.attempts("GetQueue"))
+	require.Equal(t, evrblk.NoRetries, evrblk.NewClientConfig().RetryPolicy)
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"CreateQueue": {unavailable, unavailable},
+		"Enqueue":     {unavailable, unavailable, unavailable},
+	})
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(fastRetryPolicy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.CreateQueue(context.Background(), &moab.CreateQueueRequest{Name: "my_queue"})
+	require.Error(t, err)
+	require.Equal(t, 1, server.attempts("CreateQueue"))
+
+	// Enqueue without dedupe keys is not retried
+	_, err = client.Enqueue(context.Background(), &moab.EnqueueRequest{
+		QueueName: "my_queue",
+		Entries:   []*moab.EnqueueRequestEntry{{Payload: []byte("a"), DedupeKey: "a"}, {Payload: []byte("b")}},
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, server.attempts("Enqueue"))
+
+	// Enqueue with a dedupe key on every entry is retried
+	resp, err := client.Enqueue(context.Background(), &moab.EnqueueRequest{
+		QueueName: "my_queue",
+		Entries:   []*moab.EnqueueRequestEntry{{Payload: []byte("a"), DedupeKey: "a"}, {Payload: []byte("b"), DedupeKey: "b"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 2)
+	require.Equal(t, 4, server.attempts("Enqueue"))
+
+	// Enqueue without entries has nothing to dedupe and is not retried
+	server.errors["Enqueue"] = []error{unavailable, unavailable}
+	_, err = client.Enqueue(context.Background(), &moab.EnqueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.Equal(t, 5, server.attempts("Enqueue"))
+}
+
+func TestRetryResigns(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+
+	// Server asks to wait long enough for the timestamp to change
+	retryInfo, err := status.New(codes.Unavailable, "unavailable").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Millisecond * 1100)})
+	require.NoError(t, err)
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {retryInfo.Err()},
+	})
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[1], evrblk.WithInsecure(), evrblk.WithRetryPolicy(fastRetryPolicy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond*1100)
+
+	// Every attempt is signed again: Bravo signatures of the same request differ only by timestamp
+	require.Len(t, server.signatures["GetQueue"], 2)
+	require.NotEqual(t, server.signatures["GetQueue"][0], server.signatures["GetQueue"][1])
+}
+
+func TestRetryBudget(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	server, dialer := newFailingMoabServer(t, verifier, map[string][]error{
+		"GetQueue": {unavailable, unavailable},
+	})
+
+	policy := fastRetryPolicy
+	policy.InitialBackoff = time.Second
+	policy.MaxBackoff = time.Second
+	policy.Multiplier = 1
+	policy.Budget = time.Millisecond * 100
+
+	// Server asks to wait longer than the budget
+	retryInfo, err := status.New(codes.Unavailable, "unavailable").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)})
+	require.NoError(t, err)
+	server.errors["GetQueue"] = []error{retryInfo.Err()}
+
+	client, err := moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(policy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Millisecond*500)
+	require.Equal(t, 1, server.attempts("GetQueue"))
+
+	// Backoff does not outlive the context
+	server.errors["GetQueue"] = []error{retryInfo.Err()}
+	policy.Budget = 0
+	client, err = moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(policy), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start = time.Now()
+	_, err = client.GetQueue(ctx, &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Millisecond*500)
+	require.Equal(t, 2, server.attempts("GetQueue"))
+
+	// No retries at all
+	server.errors["GetQueue"] = []error{unavailable}
+	client, err = moab.NewMoabGrpcClient("passthrough:///bufnet", signers[0], evrblk.WithInsecure(), evrblk.WithRetryPolicy(evrblk.NoRetries), dialer)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.GetQueue(context.Background(), &moab.GetQueueRequest{QueueName: "my_queue"})
+	require.Error(t, err)
+	require.Equal(t, 3, server.attempts("GetQueue"))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := evrblk.DefaultRetryPolicy
+	require.Equal(t, time.Millisecond*100, policy.Backoff(1))
+	require.Equal(t, time.Millisecond*200, policy.Backoff(2))
+	require.Equal(t, time.Millisecond*800, policy.Backoff(4))
+	require.Equal(t, time.Second*2, policy.Backoff(6))
+	require.Equal(t, time.Second*2, policy.Backoff(1000))
+
+	require.True(t, policy.Retryable(codes.Unavailable))
+	require.False(t, policy.Retryable(codes.Internal))
+}
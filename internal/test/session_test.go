@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newTestSessionVerifier creates a verifier which knows Alfa and Bravo long-term keys, and returns their secrets
+func newTestSessionVerifier(t *testing.T) (*evrblk.SignatureVerifier, map[string]string) {
+	privatePem, publicPem, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	bravoSecret := authn.GenerateBravoSecret()
+
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		alfaKeys:  map[string]string{"key_alfa_test": publicPem},
+		bravoKeys: map[string]string{"key_bravo_test": bravoSecret},
+	}, map[string]string{moabFullServiceName: "Moab"})
+
+	return verifier, map[string]string{"key_alfa_test": privatePem, "key_bravo_test": bravoSecret}
+}
+
+func TestSessionSigner(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	for apiKeyId, secret := range keys {
+		session, err := evrblk.NewSession(apiKeyId, secret, evrblk.WithSessionMethods("Moab.GetQueue"))
+		require.NoError(t, err)
+
+		signer, err := evrblk.NewSessionSigner(session.Token, session.PrivateKeyPem)
+		require.NoError(t, err)
+
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		md, _ := metadata.FromOutgoingContext(signedCtx)
+		require.Equal(t, []string{apiKeyId}, md.Get("evrblk-api-key-id"))
+
+		// Valid signature
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// Method not allowed by the session
+		signedCtx, err = signer.Sign(context.Background(), &moab.DeleteQueueRequest{QueueName: "my_queue"}, "Moab", "DeleteQueue")
+		require.NoError(t, err)
+		err = verifier.Verify(incomingContext(t, signedCtx), &moab.DeleteQueueRequest{QueueName: "my_queue"}, "Moab", "DeleteQueue")
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		// Session key does not match the token
+		other, err := evrblk.NewSession(apiKeyId, secret)
+		require.NoError(t, err)
+		_, err = evrblk.NewSessionSigner(session.Token, other.PrivateKeyPem)
+		require.Error(t, err)
+
+		// Token of another session
+		signedCtx, err = signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		md, _ = metadata.FromOutgoingContext(signedCtx)
+		md.Set("evrblk-session-token", other.Token)
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), md), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSessionSignerExpired(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+	issuedAt := time.Now().Add(-time.Hour * 2)
+
+	for apiKeyId, secret := range keys {
+		session, err := evrblk.NewSession(apiKeyId, secret,
+			evrblk.WithSessionTTL(time.Hour),
+			evrblk.WithSessionClock(evrblk.ClockFunc(func() time.Time { return issuedAt })))
+		require.NoError(t, err)
+
+		signer, err := evrblk.NewSessionSigner(session.Token, session.PrivateKeyPem)
+		require.NoError(t, err)
+
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSessionForgedIssuer(t *testing.T) {
+	verifier, keys := newTestSessionVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	// A session issued by a key unknown to the verifier, but claiming a known API key ID
+	otherPrivatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	session, err := evrblk.NewSession("key_alfa_test", otherPrivatePem)
+	require.NoError(t, err)
+	signer, err := evrblk.NewSessionSigner(session.Token, session.PrivateKeyPem)
+	require.NoError(t, err)
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// A session request claiming another API key than its token
+	session, err = evrblk.NewSession("key_bravo_test", keys["key_bravo_test"])
+	require.NoError(t, err)
+	signer, err = evrblk.NewSessionSigner(session.Token, session.PrivateKeyPem)
+	require.NoError(t, err)
+	signedCtx, err = signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	md, _ := metadata.FromOutgoingContext(signedCtx)
+	md.Set("evrblk-api-key-id", "key_alfa_test")
+	err = verifier.Verify(metadata.NewIncomingContext(context.Background(), md), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Charlie keys can't issue sessions
+	_, err = evrblk.NewSession("key_charlie_test", authn.GenerateCharlieSecret())
+	require.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	"github.com/evrblk/evrblk-go/internal"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClockSkewCorrection(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	privatePem, publicPem, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		alfaKeys: map[string]string{"key_alfa_test": publicPem},
+	}, map[string]string{moabFullServiceName: "Moab"}).WithClock(evrblk.ClockFunc(func() time.Time {
+		return now
+	}))
+
+	// Signer clock is 10 minutes behind
+	signer, err := evrblk.NewAlfaRequestSigner("key_alfa_test", privatePem, evrblk.WithClock(evrblk.ClockFunc(func() time.Time {
+		return now.Add(time.Minute * -10)
+	})))
+	require.NoError(t, err)
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	// Request is rejected and server time is reported in error details
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Error(t, err)
+
+	var evrblkErr *evrblk.Error
+	require.True(t, errors.As(internal.ErrorFromRpcError(err), &evrblkErr))
+	require.Equal(t, evrblk.Unauthenticated, evrblkErr.Code)
+	require.Equal(t, strconv.FormatInt(now.Unix(), 10), evrblkErr.Details["server_time"])
+
+	// Signer learns clock offset from the error and asks for a retry
+	require.True(t, internal.ObserveResponse(signer, nil, err))
+	require.Equal(t, time.Minute*10, signer.(evrblk.ClockSkewCorrector).ClockOffset())
+
+	// Request signed again is accepted
+	signedCtx, err = signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	// Small differences in server time reported in response headers do not change the offset
+	header := metadata.Pairs("evrblk-server-time", strconv.FormatInt(now.Add(time.Second*2).Unix(), 10))
+	require.False(t, internal.ObserveResponse(signer, header, nil))
+	require.Equal(t, time.Minute*10, signer.(evrblk.ClockSkewCorrector).ClockOffset())
+
+	// Large differences in server time reported in response headers are corrected without a retry
+	header = metadata.Pairs("evrblk-server-time", strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+	require.False(t, internal.ObserveResponse(signer, header, nil))
+	require.Equal(t, time.Minute*11, signer.(evrblk.ClockSkewCorrector).ClockOffset())
+}
+
+func TestClockSkewCorrectionDisabled(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+
+	signer, err := evrblk.NewBravoRequestSigner("key_bravo_test", authn.GenerateBravoSecret(),
+		evrblk.WithClockSkewCorrection(false))
+	require.NoError(t, err)
+
+	header := metadata.Pairs("evrblk-server-time", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+	require.False(t, internal.ObserveResponse(signer, header, nil))
+	require.Equal(t, time.Duration(0), signer.(evrblk.ClockSkewCorrector).ClockOffset())
+}
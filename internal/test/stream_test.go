@@ -0,0 +1,408 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/internal"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const streamingFullServiceName = "com.evrblk.test.StreamingApi"
+
+// streamingServiceDesc is a test service with a method of every streaming kind, reusing Moab messages:
+//   - Upload (client stream) responds with a task per received entry;
+//   - Watch (server stream) sends 3 queues named as a requested one;
+//   - Exchange (bidi stream) echoes every received queue name;
+//   - Peek (server stream) sends a queue named as its deadline before receiving a request.
+var streamingServiceDesc = grpc.ServiceDesc{
+	ServiceName: streamingFullServiceName,
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Upload", Handler: uploadHandler, ClientStreams: true},
+		{StreamName: "Watch", Handler: watchHandler, ServerStreams: true},
+		{StreamName: "Exchange", Handler: exchangeHandler, ClientStreams: true, ServerStreams: true},
+		{StreamName: "Peek", Handler: peekHandler, ServerStreams: true},
+	},
+}
+
+// init registers a descriptor of the streaming test service, so the verifier can resolve request types of its
+// server-streaming methods the same way as of generated services
+func init() {
+	method := func(name string, input string, output string, clientStreaming bool, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(".com.evrblk.moab.preview." + input),
+			OutputType:      proto.String(".com.evrblk.moab.preview." + output),
+			ClientStreaming: proto.Bool(clientStreaming),
+			ServerStreaming: proto.Bool(serverStreaming),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("internal/test/streaming.proto"),
+		Package:    proto.String("com.evrblk.test"),
+		Dependency: []string{moab.File_proto_moab_preview_api_proto.Path()},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("StreamingApi"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Upload", "EnqueueRequest", "EnqueueResponse", true, false),
+				method("Watch", "GetQueueRequest", "GetQueueResponse", false, true),
+				method("Exchange", "GetQueueRequest", "GetQueueResponse", true, true),
+				method("Peek", "GetQueueRequest", "GetQueueResponse", false, true),
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	err = protoregistry.GlobalFiles.RegisterFile(file)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func uploadHandler(_ any, stream grpc.ServerStream) error {
+	response := &moab.EnqueueResponse{}
+	for {
+		request := &moab.EnqueueRequest{}
+		err := stream.RecvMsg(request)
+		if errors.Is(err, io.EOF) {
+			return stream.SendMsg(response)
+		} else if err != nil {
+			return err
+		}
+
+		for _, entry := range request.Entries {
+			response.Tasks = append(response.Tasks, &moab.Task{QueueName: request.QueueName, Payload: entry.Payload})
+		}
+	}
+}
+
+func watchHandler(_ any, stream grpc.ServerStream) error {
+	request := &moab.GetQueueRequest{}
+	err := stream.RecvMsg(request)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		err = stream.SendMsg(&moab.GetQueueResponse{Queue: &moab.Queue{Name: request.QueueName}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func peekHandler(_ any, stream grpc.ServerStream) error {
+	name := "no_deadline"
+	if deadline, ok := stream.Context().Deadline(); ok {
+		name = strconv.FormatInt(deadline.UnixMilli(), 10)
+	}
+	err := stream.SendMsg(&moab.GetQueueResponse{Queue: &moab.Queue{Name: name}})
+	if err != nil {
+		return err
+	}
+
+	return stream.RecvMsg(&moab.GetQueueRequest{})
+}
+
+func exchangeHandler(_ any, stream grpc.ServerStream) error {
+	for {
+		request := &moab.GetQueueRequest{}
+		err := stream.RecvMsg(request)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		err = stream.SendMsg(&moab.GetQueueResponse{Queue: &moab.Queue{Name: request.QueueName}})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// streamingClient is written the same way as clients generated by protoc-gen-go-grpc
+type streamingClient struct {
+	conn *grpc.ClientConn
+}
+
+func (c *streamingClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[moab.EnqueueRequest, moab.EnqueueResponse], error) {
+	stream, err := c.conn.NewStream(ctx, &streamingServiceDesc.Streams[0], "/"+streamingFullServiceName+"/Upload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[moab.EnqueueRequest, moab.EnqueueResponse]{ClientStream: stream}, nil
+}
+
+func (c *streamingClient) Watch(ctx context.Context, in *moab.GetQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[moab.GetQueueResponse], error) {
+	stream, err := c.conn.NewStream(ctx, &streamingServiceDesc.Streams[1], "/"+streamingFullServiceName+"/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[moab.GetQueueRequest, moab.GetQueueResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (c *streamingClient) Exchange(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[moab.GetQueueRequest, moab.GetQueueResponse], error) {
+	stream, err := c.conn.NewStream(ctx, &streamingServiceDesc.Streams[2], "/"+streamingFullServiceName+"/Exchange", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[moab.GetQueueRequest, moab.GetQueueResponse]{ClientStream: stream}, nil
+}
+
+func (c *streamingClient) Peek(ctx context.Context, in *moab.GetQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[moab.GetQueueResponse], error) {
+	stream, err := c.conn.NewStream(ctx, &streamingServiceDesc.Streams[3], "/"+streamingFullServiceName+"/Peek", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[moab.GetQueueRequest, moab.GetQueueResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// newStreamingClient starts an in-memory gRPC server of the streaming test service with the verifier installed
+func newStreamingClient(t *testing.T, verifier *evrblk.SignatureVerifier) *streamingClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(verifier.StreamServerInterceptor()))
+	server.RegisterService(&streamingServiceDesc, nil)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return &streamingClient{conn: conn}
+}
+
+func TestSignedStreams(t *testing.T) {
+	for _, version := range []struct {
+		name string
+		opts []evrblk.SignerOption
+	}{
+		{"v1", nil},
+		{"v2", []evrblk.SignerOption{evrblk.WithSignatureVersion2()}},
+	} {
+		t.Run(version.name, func(t *testing.T) {
+			verifier, signers := newTestVerifier(t, version.opts...)
+			client := newStreamingClient(t, verifier)
+			ctx := context.Background()
+			config := evrblk.NewClientConfig()
+
+			for _, signer := range signers {
+				// Client stream
+				upload, err := internal.ClientStream[moab.EnqueueRequest, moab.EnqueueResponse](ctx, config, signer, "Streaming", "Upload", evrblk.DataPlaneMethod, client.Upload)
+				require.NoError(t, err)
+				for _, payload := range []string{"a", "b", "c"} {
+					err = upload.Send(&moab.EnqueueRequest{
+						QueueName: "my_queue",
+						Entries:   []*moab.EnqueueRequestEntry{{Payload: []byte(payload)}},
+					})
+					require.NoError(t, err)
+				}
+				response, err := upload.CloseAndRecv()
+				require.NoError(t, err)
+				require.Len(t, response.Tasks, 3)
+				require.Equal(t, []byte("c"), response.Tasks[2].Payload)
+
+				// Server stream
+				watch, err := internal.ServerStream[moab.GetQueueRequest, moab.GetQueueResponse](ctx, config, signer, "Streaming", "Watch", &moab.GetQueueRequest{QueueName: "my_queue"}, evrblk.DataPlaneMethod, client.Watch)
+				require.NoError(t, err)
+				var received int
+				for {
+					queue, err := watch.Recv()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					require.NoError(t, err)
+					require.Equal(t, "my_queue", queue.Queue.Name)
+					received++
+				}
+				require.Equal(t, 3, received)
+
+				// Bidi stream
+				exchange, err := internal.BidiStream[moab.GetQueueRequest, moab.GetQueueResponse](ctx, config, signer, "Streaming", "Exchange", evrblk.DataPlaneMethod, client.Exchange)
+				require.NoError(t, err)
+				for _, name := range []string{"queue_1", "queue_2"} {
+					err = exchange.Send(&moab.GetQueueRequest{QueueName: name})
+					require.NoError(t, err)
+					queue, err := exchange.Recv()
+					require.NoError(t, err)
+					require.Equal(t, name, queue.Queue.Name)
+				}
+				require.NoError(t, exchange.CloseSend())
+				_, err = exchange.Recv()
+				require.ErrorIs(t, err, io.EOF)
+			}
+		})
+	}
+}
+
+func TestSignedStreamsTampered(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	client := newStreamingClient(t, verifier)
+	ctx := context.Background()
+	config := evrblk.NewClientConfig()
+
+	requireUnauthenticated := func(t *testing.T, exchange grpc.BidiStreamingClient[moab.GetQueueRequest, moab.GetQueueResponse]) {
+		_, err := exchange.Recv()
+		var e *evrblk.Error
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, evrblk.Unauthenticated, e.Code)
+	}
+
+	for _, signer := range signers {
+		// Unsigned opening of a stream
+		exchange, err := internal.BidiStream[moab.GetQueueRequest, moab.GetQueueResponse](ctx, config, evrblk.NewNoOpSigner(), "Streaming", "Exchange", evrblk.DataPlaneMethod, client.Exchange)
+		require.NoError(t, err)
+		requireUnauthenticated(t, exchange)
+
+		// Unsigned message
+		signedCtx, _, err := signer.(evrblk.StreamSigner).SignStream(ctx, "Streaming", "Exchange")
+		require.NoError(t, err)
+		raw, err := client.Exchange(signedCtx)
+		require.NoError(t, err)
+		require.NoError(t, raw.Send(&moab.GetQueueRequest{QueueName: "my_queue"}))
+		_, err = raw.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// Reordered messages
+		signedCtx, messageSigner, err := signer.(evrblk.StreamSigner).SignStream(ctx, "Streaming", "Exchange")
+		require.NoError(t, err)
+		_, err = messageSigner.SignMessage(&moab.GetQueueRequest{QueueName: "queue_1"})
+		require.NoError(t, err)
+		second, err := messageSigner.SignMessage(&moab.GetQueueRequest{QueueName: "queue_2"})
+		require.NoError(t, err)
+		raw, err = client.Exchange(signedCtx)
+		require.NoError(t, err)
+		require.NoError(t, raw.SendMsg(second))
+		_, err = raw.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// Modified message
+		signedCtx, messageSigner, err = signer.(evrblk.StreamSigner).SignStream(ctx, "Streaming", "Exchange")
+		require.NoError(t, err)
+		first, err := messageSigner.SignMessage(&moab.GetQueueRequest{QueueName: "queue_1"})
+		require.NoError(t, err)
+		first.(*moab.GetQueueRequest).QueueName = "queue_2"
+		raw, err = client.Exchange(signedCtx)
+		require.NoError(t, err)
+		require.NoError(t, raw.SendMsg(first))
+		_, err = raw.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSignedServerStreamVerifiedOnOpen(t *testing.T) {
+	verifier, signers := newTestVerifier(t, evrblk.WithSignatureVersion2())
+	client := newStreamingClient(t, verifier)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	deadline := time.Now().Add(time.Second * 30).Truncate(time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	for _, signer := range signers {
+		// Unsigned request is rejected before a handler sends anything
+		peek, err := client.Peek(context.Background(), request)
+		require.NoError(t, err)
+		_, err = peek.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// Server context without a deadline (e.g. tampered gRPC timeout) gets the signed one
+		signedCtx, err := signer.Sign(ctx, request, "Streaming", "Peek")
+		require.NoError(t, err)
+		md, _ := metadata.FromOutgoingContext(signedCtx)
+		peek, err = client.Peek(metadata.NewOutgoingContext(context.Background(), md), request)
+		require.NoError(t, err)
+		response, err := peek.Recv()
+		require.NoError(t, err)
+		require.Equal(t, strconv.FormatInt(deadline.UnixMilli(), 10), response.Queue.Name)
+		_, err = peek.Recv()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestStreamClientConfig(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	client := newStreamingClient(t, verifier)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	// Default timeout of a method category is applied to a stream
+	config := evrblk.NewClientConfig(evrblk.WithTimeouts(evrblk.Timeouts{DataPlane: time.Second}))
+	start := time.Now()
+	peek, err := internal.ServerStream[moab.GetQueueRequest, moab.GetQueueResponse](context.Background(), config, signers[0], "Streaming", "Peek", request, evrblk.DataPlaneMethod, client.Peek)
+	require.NoError(t, err)
+	response, err := peek.Recv()
+	require.NoError(t, err)
+	deadline, err := strconv.ParseInt(response.Queue.Name, 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, start.Add(time.Second).UnixMilli(), deadline, 100)
+	_, err = peek.Recv()
+	require.ErrorIs(t, err, io.EOF)
+
+	// Failed streams open the circuit, then streams fail fast
+	config = evrblk.NewClientConfig(evrblk.WithCircuitBreaker(evrblk.CircuitBreakerPolicy{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenCalls: 1}))
+	missing := func(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[moab.GetQueueRequest, moab.GetQueueResponse], error) {
+		stream, err := client.conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, "/"+streamingFullServiceName+"/Missing", opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &grpc.GenericClientStream[moab.GetQueueRequest, moab.GetQueueResponse]{ClientStream: stream}, nil
+	}
+
+	exchange, err := internal.BidiStream[moab.GetQueueRequest, moab.GetQueueResponse](context.Background(), config, signers[0], "Streaming", "Missing", evrblk.DataPlaneMethod, missing)
+	require.NoError(t, err)
+	_, err = exchange.Recv()
+	var e *evrblk.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, evrblk.InternalFailure, e.Code)
+	require.Equal(t, float64(evrblk.CircuitStateOpen), gaugeValue(t, "Streaming", "write"))
+
+	_, err = internal.BidiStream[moab.GetQueueRequest, moab.GetQueueResponse](context.Background(), config, signers[0], "Streaming", "Missing", evrblk.DataPlaneMethod, missing)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, evrblk.CircuitOpen, e.Code)
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/evrblk/evrblk-go/authn"
+	_ "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const signatureVectorsFile = "testdata/signature_vectors.json"
+
+// signatureVectors are golden test vectors shared with other SDKs
+type signatureVectors struct {
+	Keys    map[string]signatureVectorKey `json:"keys"`
+	Vectors []signatureVector             `json:"vectors"`
+}
+
+type signatureVectorKey struct {
+	ApiKeyId      string `json:"api_key_id"`
+	PrivateKeyPem string `json:"private_key_pem,omitempty"`
+	PublicKeyPem  string `json:"public_key_pem,omitempty"`
+	Secret        string `json:"secret,omitempty"`
+}
+
+type signatureVector struct {
+	Name                string          `json:"name"`
+	Key                 string          `json:"key"`
+	Timestamp           int64           `json:"timestamp"`
+	Service             string          `json:"service"`
+	Method              string          `json:"method"`
+	RequestType         string          `json:"request_type"`
+	Request             json.RawMessage `json:"request"`
+	CanonicalRequestHex string          `json:"canonical_request_hex"`
+	PayloadHex          string          `json:"payload_hex"`
+	Signature           string          `json:"signature"`
+
+	// Signed headers of signature version 2, version 1 if not set
+	Headers map[string][]string `json:"headers,omitempty"`
+
+	// Randomized signatures (ECDSA) can only be verified, not reproduced
+	Randomized bool `json:"randomized,omitempty"`
+}
+
+func readSignatureVectors(t *testing.T) *signatureVectors {
+	data, err := os.ReadFile(signatureVectorsFile)
+	require.NoError(t, err)
+
+	var vectors signatureVectors
+	err = json.Unmarshal(data, &vectors)
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors.Vectors)
+
+	return &vectors
+}
+
+// unmarshalVectorRequest creates a request of a registered proto type from its JSON representation
+func unmarshalVectorRequest(t *testing.T, requestType string, requestJson []byte) proto.Message {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(requestType))
+	require.NoError(t, err)
+
+	request := messageType.New().Interface()
+	err = protojson.Unmarshal(requestJson, request)
+	require.NoError(t, err)
+
+	return request
+}
+
+func TestSignatureVectors(t *testing.T) {
+	vectors := readSignatureVectors(t)
+
+	for _, v := range vectors.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			key, ok := vectors.Keys[v.Key]
+			require.True(t, ok, "unknown key %s", v.Key)
+
+			request := unmarshalVectorRequest(t, v.RequestType, v.Request)
+			now := time.Unix(v.Timestamp, 0)
+
+			// Canonical encoding of the request
+			canonicalRequest, err := authn.CanonicalRequest(request)
+			require.NoError(t, err)
+			require.Equal(t, v.CanonicalRequestHex, hex.EncodeToString(canonicalRequest))
+
+			// Signed payload
+			require.Equal(t, v.PayloadHex, hex.EncodeToString(vectorPayload(v, canonicalRequest)))
+
+			var signOptions []authn.SignOption
+			var verifyOptions []authn.VerifyOption
+			if v.Headers != nil {
+				signOptions = append(signOptions, authn.WithSignedHeaders(v.Headers))
+				verifyOptions = append(verifyOptions, authn.WithVerifiedHeaders(v.Headers))
+			}
+
+			switch {
+			case key.PrivateKeyPem != "":
+				err = authn.VerifyAlfaSignature(v.Signature, v.Timestamp, now, key.PublicKeyPem, request, v.Service, v.Method, verifyOptions...)
+				require.NoError(t, err)
+
+				if !v.Randomized {
+					signature, err := authn.SignAlfa(v.Timestamp, key.PrivateKeyPem, request, v.Service, v.Method, signOptions...)
+					require.NoError(t, err)
+					require.Equal(t, v.Signature, signature)
+				}
+
+			case v.Key == "bravo":
+				signature, err := authn.SignBravo(v.Timestamp, key.Secret, request, v.Service, v.Method, signOptions...)
+				require.NoError(t, err)
+				require.Equal(t, v.Signature, signature)
+
+				hashedSecret, err := authn.HashBravoSecretWithDate(key.Secret, authn.GetDateOfTimestamp(v.Timestamp))
+				require.NoError(t, err)
+				err = authn.VerifyBravoSignature(v.Signature, v.Timestamp, now, hashedSecret, request, v.Service, v.Method, verifyOptions...)
+				require.NoError(t, err)
+
+			case v.Key == "charlie":
+				signature, err := authn.SignCharlie(v.Timestamp, key.Secret, request, v.Service, v.Method, signOptions...)
+				require.NoError(t, err)
+				require.Equal(t, v.Signature, signature)
+
+				hashedSecret, err := authn.HashCharlieSecret(key.Secret, authn.GetDateOfTimestamp(v.Timestamp), v.Service)
+				require.NoError(t, err)
+				err = authn.VerifyCharlieSignature(v.Signature, v.Timestamp, now, hashedSecret, request, v.Service, v.Method, verifyOptions...)
+				require.NoError(t, err)
+
+			default:
+				t.Fatalf("unsupported key %s", v.Key)
+			}
+		})
+	}
+}
+
+// vectorPayload builds a signed payload following the specification in package authn, independently of its
+// implementation
+func vectorPayload(v signatureVector, canonicalRequest []byte) []byte {
+	// Version 1: timestamp, service and method, canonical request
+	if v.Headers == nil {
+		payload := binary.BigEndian.AppendUint64(nil, uint64(v.Timestamp))
+		payload = append(payload, v.Service+"."+v.Method...)
+		return append(payload, canonicalRequest...)
+	}
+
+	// Version 2: prefix, timestamp, length-prefixed service and method, signed headers, canonical request
+	lengthPrefixed := func(payload []byte, s string) []byte {
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(s)))
+		return append(payload, s...)
+	}
+
+	payload := []byte("EVRBLK-SIGNATURE-V2\x00")
+	payload = binary.BigEndian.AppendUint64(payload, uint64(v.Timestamp))
+	payload = lengthPrefixed(payload, v.Service+"."+v.Method)
+
+	names := make([]string, 0, len(v.Headers))
+	for name := range v.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload = binary.BigEndian.AppendUint32(payload, uint32(len(names)))
+	for _, name := range names {
+		payload = lengthPrefixed(payload, name)
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(v.Headers[name])))
+		for _, value := range v.Headers[name] {
+			payload = lengthPrefixed(payload, value)
+		}
+	}
+
+	return append(payload, canonicalRequest...)
+}
@@ -0,0 +1,400 @@
+package test
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	"github.com/evrblk/evrblk-go/authn"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const moabFullServiceName = "com.evrblk.moab.preview.MoabPreviewApi"
+
+type testKeyLookup struct {
+	alfaKeys    map[string]string
+	bravoKeys   map[string]string
+	charlieKeys map[string]string
+}
+
+func (l *testKeyLookup) AlfaPublicKey(ctx context.Context, apiKeyId string) (string, error) {
+	publicPem, ok := l.alfaKeys[apiKeyId]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return publicPem, nil
+}
+
+func (l *testKeyLookup) BravoHashedSecret(ctx context.Context, apiKeyId string, date string) ([]byte, error) {
+	secret, ok := l.bravoKeys[apiKeyId]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return authn.HashBravoSecretWithDate(secret, date)
+}
+
+func (l *testKeyLookup) CharlieHashedSecret(ctx context.Context, apiKeyId string, date string, service string) ([]byte, error) {
+	secret, ok := l.charlieKeys[apiKeyId]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return authn.HashCharlieSecret(secret, date, service)
+}
+
+// incomingContext turns outgoing metadata of a signed context into incoming metadata, as a server would see it
+func incomingContext(t *testing.T, signedCtx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(signedCtx)
+	require.True(t, ok)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// newTestVerifier creates a verifier and signers for Alfa, Bravo and Charlie keys known to it
+func newTestVerifier(t *testing.T, opts ...evrblk.SignerOption) (*evrblk.SignatureVerifier, []evrblk.RequestSigner) {
+	privatePem, publicPem, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	bravoSecret := authn.GenerateBravoSecret()
+	charlieSecret := authn.GenerateCharlieSecret()
+
+	alfaSigner, err := evrblk.NewRequestSigner("key_alfa_test", privatePem, opts...)
+	require.NoError(t, err)
+	bravoSigner, err := evrblk.NewRequestSigner("key_bravo_test", bravoSecret, opts...)
+	require.NoError(t, err)
+	charlieSigner, err := evrblk.NewRequestSigner("key_charlie_test", charlieSecret, opts...)
+	require.NoError(t, err)
+
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		alfaKeys:    map[string]string{"key_alfa_test": publicPem},
+		bravoKeys:   map[string]string{"key_bravo_test": bravoSecret},
+		charlieKeys: map[string]string{"key_charlie_test": charlieSecret},
+	}, map[string]string{moabFullServiceName: "Moab", streamingFullServiceName: "Streaming"})
+
+	return verifier, []evrblk.RequestSigner{alfaSigner, bravoSigner, charlieSigner}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	interceptor := verifier.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + moabFullServiceName + "/GetQueue"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &moab.GetQueueResponse{}, nil
+	}
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	for _, signer := range signers {
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// Valid signature
+		_, err = interceptor(incomingContext(t, signedCtx), request, info, handler)
+		require.NoError(t, err)
+
+		// Modified request
+		_, err = interceptor(incomingContext(t, signedCtx), &moab.GetQueueRequest{QueueName: "other_queue"}, info, handler)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// Another method
+		_, err = interceptor(incomingContext(t, signedCtx), request, &grpc.UnaryServerInfo{
+			FullMethod: "/" + moabFullServiceName + "/DeleteQueue",
+		}, handler)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// No signature at all
+	_, err := interceptor(metadata.NewIncomingContext(context.Background(), metadata.MD{}), request, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Unknown service
+	signedCtx, err := signers[0].Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	_, err = interceptor(incomingContext(t, signedCtx), request, &grpc.UnaryServerInfo{
+		FullMethod: "/com.evrblk.jakal.preview.JakalPreviewApi/GetQueue",
+	}, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestVerifyUnknownApiKey(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	privatePem, _, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+
+	signer, err := evrblk.NewAlfaRequestSigner("key_alfa_unknown", privatePem)
+	require.NoError(t, err)
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.True(t, evrblk.IsApiKeyInvalid(err))
+
+	// Other rejections of known keys are not reported as an invalid key
+	knownCtx, err := signers[0].Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = verifier.Verify(incomingContext(t, knownCtx), &moab.GetQueueRequest{QueueName: "other_queue"}, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.False(t, evrblk.IsApiKeyInvalid(err))
+
+	// Unknown key type
+	md, _ := metadata.FromOutgoingContext(signedCtx)
+	md.Set("evrblk-api-key-id", "key_zulu_test")
+	err = verifier.Verify(metadata.NewIncomingContext(context.Background(), md), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestVerifyReplay(t *testing.T) {
+	verifier, signers := newTestVerifier(t, evrblk.WithSignatureVersion2())
+	cache, err := authn.NewMemoryReplayCache(1000)
+	require.NoError(t, err)
+	verifier = verifier.WithReplayCache(cache)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	for _, signer := range signers {
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// First request is accepted
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// The same signed request is rejected
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Contains(t, status.Convert(err).Message(), "replayed")
+
+		// The same request signed again (e.g. retried) within the same second has another nonce
+		signedCtx, err = signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+	}
+
+	// Requests without a signed nonce are rejected
+	_, v1Signers := newTestVerifier(t)
+	signedCtx, err := v1Signers[0].Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "evrblk-nonce")
+
+	// Re-encoded signatures are rejected as malformed
+	request = &moab.GetQueueRequest{QueueName: "other_queue"}
+	signedCtx, err = signers[1].Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	ctx := incomingContext(t, signedCtx)
+	require.NoError(t, verifier.Verify(ctx, request, "Moab", "GetQueue"))
+	md, _ := metadata.FromIncomingContext(ctx)
+	md.Set("evrblk-signature", strings.ToUpper(md.Get("evrblk-signature")[0]))
+	err = verifier.Verify(metadata.NewIncomingContext(context.Background(), md), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Malleated ECDSA signature (r, n-s) is valid, but it is the same request
+	request = &moab.GetQueueRequest{QueueName: "third_queue"}
+	signedCtx, err = signers[0].Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+	ctx = incomingContext(t, signedCtx)
+	require.NoError(t, verifier.Verify(ctx, request, "Moab", "GetQueue"))
+
+	md, _ = metadata.FromIncomingContext(ctx)
+	der, err := base64.StdEncoding.DecodeString(md.Get("evrblk-signature")[0])
+	require.NoError(t, err)
+	sig := authn.ECDSASignature{}
+	_, err = asn1.Unmarshal(der, &sig)
+	require.NoError(t, err)
+	sig.S.Sub(elliptic.P256().Params().N, sig.S)
+	der, err = asn1.Marshal(sig)
+	require.NoError(t, err)
+	md.Set("evrblk-signature", base64.StdEncoding.EncodeToString(der))
+
+	err = verifier.Verify(metadata.NewIncomingContext(context.Background(), md), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "replayed")
+}
+
+func TestVerifyReplayVerifierClock(t *testing.T) {
+	// Verifier clock is far behind the system clock, requests are remembered as long as it accepts them
+	now := time.Unix(1733240571, 0)
+	clock := evrblk.ClockFunc(func() time.Time {
+		return now
+	})
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	privatePem, publicPem, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+	cache, err := authn.NewMemoryReplayCache(100)
+	require.NoError(t, err)
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		alfaKeys: map[string]string{"key_alfa_test": publicPem},
+	}, map[string]string{moabFullServiceName: "Moab"}).WithClock(clock).WithReplayCache(cache)
+
+	signer, err := evrblk.NewAlfaRequestSigner("key_alfa_test", privatePem, evrblk.WithClock(clock), evrblk.WithSignatureVersion2())
+	require.NoError(t, err)
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	require.NoError(t, verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue"))
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "replayed")
+}
+
+func TestVerifyClockSkew(t *testing.T) {
+	now := time.Unix(1733240571, 0)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	privatePem, publicPem, err := authn.GenerateAlfaKeys()
+	require.NoError(t, err)
+
+	verifier := evrblk.NewSignatureVerifier(&testKeyLookup{
+		alfaKeys: map[string]string{"key_alfa_test": publicPem},
+	}, map[string]string{moabFullServiceName: "Moab", streamingFullServiceName: "Streaming"}).WithClock(evrblk.ClockFunc(func() time.Time {
+		return now
+	}))
+
+	// Signer clock is 3 minutes behind
+	signer, err := evrblk.NewAlfaRequestSigner("key_alfa_test", privatePem, evrblk.WithClock(evrblk.ClockFunc(func() time.Time {
+		return now.Add(time.Minute * -3)
+	})))
+	require.NoError(t, err)
+
+	signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	// Accepted with default 5 minutes window
+	err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.NoError(t, err)
+
+	// Rejected when only 1 minute in the past is allowed
+	err = verifier.WithVerifyOptions(authn.WithMaxPastSkew(time.Minute)).
+		Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestVerifySignatureVersion(t *testing.T) {
+	verifier, signers := newTestVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	for _, signer := range signers {
+		signedCtx, err := signer.Sign(context.Background(), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(signedCtx)
+		require.Equal(t, []string{authn.SignatureVersion1}, md.Get("evrblk-signature-version"))
+
+		// Older clients do not send a version
+		legacy := md.Copy()
+		legacy.Delete("evrblk-signature-version")
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), legacy), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// Unknown version
+		unknown := md.Copy()
+		unknown.Set("evrblk-signature-version", "999")
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), unknown), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Contains(t, status.Convert(err).Message(), "unsupported signature version")
+	}
+}
+
+func TestVerifySignatureVersion2(t *testing.T) {
+	verifier, signers := newTestVerifier(t, evrblk.WithSignatureVersion2("x-tenant-id"))
+	_, v1Signers := newTestVerifier(t)
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant_1")
+
+	for _, signer := range signers {
+		signedCtx, err := signer.Sign(ctx, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(signedCtx)
+		require.Equal(t, []string{authn.SignatureVersion2}, md.Get("evrblk-signature-version"))
+		require.Equal(t, []string{"evrblk-api-key-id,evrblk-nonce,x-tenant-id"}, md.Get("evrblk-signed-headers"))
+
+		// Valid signature, also when signed headers are required
+		err = verifier.Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+		err = verifier.WithRequiredSignedHeaders("x-tenant-id").Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		// Modified signed header
+		tampered := md.Copy()
+		tampered.Set("x-tenant-id", "tenant_2")
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), tampered), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// Header removed from the list of signed headers
+		tampered = md.Copy()
+		tampered.Set("evrblk-signed-headers", "evrblk-api-key-id")
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), tampered), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// API key ID must be signed
+		tampered = md.Copy()
+		tampered.Set("evrblk-signed-headers", "x-tenant-id")
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), tampered), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Contains(t, status.Convert(err).Message(), "evrblk-api-key-id header must be signed")
+
+		// Downgrade to version 1
+		tampered = md.Copy()
+		tampered.Set("evrblk-signature-version", authn.SignatureVersion1)
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), tampered), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// Version 1 is accepted until signed headers are required
+	for _, signer := range v1Signers {
+		signedCtx, err := signer.Sign(ctx, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		err = verifier.WithRequiredSignedHeaders().Verify(incomingContext(t, signedCtx), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestUnaryServerInterceptorSignedDeadline(t *testing.T) {
+	verifier, signers := newTestVerifier(t, evrblk.WithSignatureVersion2())
+	interceptor := verifier.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + moabFullServiceName + "/GetQueue"}
+	request := &moab.GetQueueRequest{QueueName: "my_queue"}
+
+	deadline := time.Now().Add(time.Second * 30).Truncate(time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	for _, signer := range signers {
+		signedCtx, err := signer.Sign(ctx, request, "Moab", "GetQueue")
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(signedCtx)
+		require.Equal(t, []string{"evrblk-api-key-id,evrblk-deadline,evrblk-nonce"}, md.Get("evrblk-signed-headers"))
+
+		// Server context without a deadline (e.g. tampered gRPC timeout) gets the signed one
+		_, err = interceptor(incomingContext(t, signedCtx), request, info, func(ctx context.Context, req any) (any, error) {
+			handlerDeadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			require.Equal(t, deadline, handlerDeadline)
+			return &moab.GetQueueResponse{}, nil
+		})
+		require.NoError(t, err)
+
+		// Extended deadline
+		tampered := md.Copy()
+		tampered.Set("evrblk-deadline", fmt.Sprintf("%d", deadline.Add(time.Hour).UnixMilli()))
+		err = verifier.Verify(metadata.NewIncomingContext(context.Background(), tampered), request, "Moab", "GetQueue")
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
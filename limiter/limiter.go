@@ -0,0 +1,281 @@
+// Package limiter throttles calls of generated clients on the client side according to service limits of an account,
+// so they are not rejected by servers with ResourceExhausted errors.
+//
+// A limiter is created from limits returned by MyAccount GetAccount and passed to clients with evrblk.WithRateLimiter:
+//
+//	accountClient, err := myaccount.NewMyAccountGrpcClient(address, signer)
+//	l, err := limiter.Fetch(ctx, accountClient)
+//	moabClient, err := moab.NewMoabGrpcClient(address, signer, evrblk.WithRateLimiter(l))
+//
+// Rates of service limits are requests per second, every call consumes one token. Enqueue and Dequeue are limited
+// per queue, all other calls per account.
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	evrblk "github.com/evrblk/evrblk-go"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	myaccount "github.com/evrblk/evrblk-go/myaccount/preview"
+	"google.golang.org/protobuf/proto"
+)
+
+// Category is a group of methods of a service sharing a rate limit
+type Category string
+
+const (
+	ControlPlaneRead   Category = "control_plane_read"
+	ControlPlaneUpdate Category = "control_plane_update"
+	DataPlane          Category = "data_plane"
+	Enqueue            Category = "enqueue"
+	Dequeue            Category = "dequeue"
+	PurgeQueue         Category = "purge_queue"
+)
+
+// moabCategories classifies Moab methods by service limits which apply to them
+var moabCategories = map[string]Category{
+	"CreateQueue":    ControlPlaneUpdate,
+	"GetQueue":       ControlPlaneRead,
+	"UpdateQueue":    ControlPlaneUpdate,
+	"DeleteQueue":    ControlPlaneUpdate,
+	"ListQueues":     ControlPlaneRead,
+	"GetTask":        DataPlane,
+	"Enqueue":        Enqueue,
+	"Dequeue":        Dequeue,
+	"ReportStatus":   DataPlane,
+	"DeleteTasks":    DataPlane,
+	"RestartTasks":   DataPlane,
+	"PurgeQueue":     PurgeQueue,
+	"CreateSchedule": ControlPlaneUpdate,
+	"GetSchedule":    ControlPlaneRead,
+	"UpdateSchedule": ControlPlaneUpdate,
+	"DeleteSchedule": ControlPlaneUpdate,
+}
+
+// iamCategories classifies IAM methods by service limits which apply to them
+var iamCategories = map[string]Category{
+	"CreateRole":   ControlPlaneUpdate,
+	"GetRole":      ControlPlaneRead,
+	"UpdateRole":   ControlPlaneUpdate,
+	"ListRoles":    ControlPlaneRead,
+	"DeleteRole":   ControlPlaneUpdate,
+	"CreateUser":   ControlPlaneUpdate,
+	"GetUser":      ControlPlaneRead,
+	"UpdateUser":   ControlPlaneUpdate,
+	"ListUsers":    ControlPlaneRead,
+	"DeleteUser":   ControlPlaneUpdate,
+	"CreateApiKey": ControlPlaneUpdate,
+	"GetApiKey":    ControlPlaneRead,
+	"ListApiKeys":  ControlPlaneRead,
+	"DeleteApiKey": ControlPlaneUpdate,
+}
+
+// Limiter is a set of token buckets, one per service and category (and per queue for Enqueue and Dequeue). Calls of
+// methods not covered by service limits are not throttled.
+type Limiter struct {
+	mu      sync.Mutex
+	rates   map[string]map[Category]int64
+	buckets map[bucketKey]*bucket
+}
+
+var _ evrblk.RateLimiter = &Limiter{}
+
+type bucketKey struct {
+	service  string
+	category Category
+	queue    string
+}
+
+// New creates a limiter with given service limits
+func New(limits *myaccount.ServiceLimits) *Limiter {
+	l := &Limiter{}
+	l.Update(limits)
+	return l
+}
+
+// Fetch gets service limits of the account with MyAccount GetAccount and creates a limiter with them
+func Fetch(ctx context.Context, client myaccount.MyAccountApi) (*Limiter, error) {
+	resp, err := client.GetAccount(ctx, &myaccount.GetAccountRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return New(resp.GetAccount().GetServiceLimits()), nil
+}
+
+// Refresh gets service limits of the account again (e.g. after a limit increase) and updates the limiter
+func (l *Limiter) Refresh(ctx context.Context, client myaccount.MyAccountApi) error {
+	resp, err := client.GetAccount(ctx, &myaccount.GetAccountRequest{})
+	if err != nil {
+		return err
+	}
+
+	l.Update(resp.GetAccount().GetServiceLimits())
+	return nil
+}
+
+// Update replaces service limits. Tokens already in buckets are kept, up to new limits.
+func (l *Limiter) Update(limits *myaccount.ServiceLimits) {
+	moabLimits := limits.GetMoabServiceLimits()
+	iamLimits := limits.GetIamServiceLimits()
+
+	rates := map[string]map[Category]int64{
+		"Moab": {
+			ControlPlaneRead:   moabLimits.GetControlPlaneReadRequestRate(),
+			ControlPlaneUpdate: moabLimits.GetControlPlaneUpdateRequestRate(),
+			DataPlane:          moabLimits.GetDataPlaneRequestRate(),
+			Enqueue:            moabLimits.GetEnqueuePerQueueRequestRate(),
+			Dequeue:            moabLimits.GetDequeuePerQueueRequestRate(),
+			PurgeQueue:         moabLimits.GetPurgeQueueRequestRate(),
+		},
+		"IAM": {
+			ControlPlaneRead:   iamLimits.GetControlPlaneReadRequestRate(),
+			ControlPlaneUpdate: iamLimits.GetControlPlaneUpdateRequestRate(),
+		},
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rates = rates
+	if l.buckets == nil {
+		l.buckets = make(map[bucketKey]*bucket)
+	}
+	for key, b := range l.buckets {
+		b.setRate(rates[key.service][key.category], time.Now())
+	}
+}
+
+// Wait blocks until a call of a method can be sent. If the context is done first, or its deadline comes before the
+// call could be sent, a context error is returned and the call does not consume a token.
+func (l *Limiter) Wait(ctx context.Context, service string, method string, request proto.Message) error {
+	b := l.bucket(service, method, request)
+	if b == nil {
+		return nil
+	}
+
+	now := time.Now()
+	delay := b.reserve(now)
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		b.cancel()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// bucket returns a bucket for a call, or nil if the call is not limited
+func (l *Limiter) bucket(service string, method string, request proto.Message) *bucket {
+	var category Category
+	switch service {
+	case "Moab":
+		category = moabCategories[method]
+	case "IAM":
+		category = iamCategories[method]
+	}
+	if category == "" {
+		return nil
+	}
+
+	key := bucketKey{
+		service:  service,
+		category: category,
+		queue:    queueName(request),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := l.rates[service][category]
+	if rate <= 0 {
+		return nil
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newBucket(rate, time.Now())
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// queueName returns a queue of calls limited per queue
+func queueName(request proto.Message) string {
+	switch request := request.(type) {
+	case *moab.EnqueueRequest:
+		return request.QueueName
+	case *moab.DequeueRequest:
+		return request.QueueName
+	default:
+		return ""
+	}
+}
+
+// bucket is a token bucket refilled with rate tokens per second, holding at most rate tokens (one second of burst).
+// Tokens can go negative: a reservation waits until its token is refilled.
+type bucket struct {
+	mu       sync.Mutex
+	rate     float64
+	tokens   float64
+	updateAt time.Time
+}
+
+func newBucket(rate int64, now time.Time) *bucket {
+	return &bucket{
+		rate:     float64(rate),
+		tokens:   float64(rate),
+		updateAt: now,
+	}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+}
+
+func (b *bucket) setRate(rate int64, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.rate = float64(rate)
+	b.tokens = min(b.tokens, b.rate)
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updateAt); elapsed > 0 {
+		b.tokens = min(b.rate, b.tokens+elapsed.Seconds()*b.rate)
+		b.updateAt = now
+	}
+}
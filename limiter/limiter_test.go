@@ -0,0 +1,135 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	iam "github.com/evrblk/evrblk-go/iam/preview"
+	moab "github.com/evrblk/evrblk-go/moab/preview"
+	myaccount "github.com/evrblk/evrblk-go/myaccount/preview"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type testAccountClient struct {
+	limits *myaccount.ServiceLimits
+	err    error
+}
+
+func (c *testAccountClient) GetAccount(ctx context.Context, request *myaccount.GetAccountRequest) (*myaccount.GetAccountResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &myaccount.GetAccountResponse{Account: &myaccount.Account{Id: "test", ServiceLimits: c.limits}}, nil
+}
+
+func testLimits(rate int64) *myaccount.ServiceLimits {
+	return &myaccount.ServiceLimits{
+		MoabServiceLimits: &myaccount.MoabServiceLimits{
+			EnqueuePerQueueRequestRate:    rate,
+			DequeuePerQueueRequestRate:    rate,
+			ControlPlaneReadRequestRate:   rate,
+			ControlPlaneUpdateRequestRate: rate,
+		},
+		IamServiceLimits: &myaccount.IAMServiceLimits{
+			ControlPlaneReadRequestRate: rate,
+		},
+	}
+}
+
+// waitImmediately checks that a call is not throttled
+func waitImmediately(t *testing.T, l *Limiter, service string, method string, request proto.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*5)
+	defer cancel()
+
+	require.NoError(t, l.Wait(ctx, service, method, request))
+}
+
+func TestLimiterPerQueue(t *testing.T) {
+	l := New(testLimits(10))
+	ctx := context.Background()
+
+	// Burst of one second
+	for i := 0; i < 10; i++ {
+		waitImmediately(t, l, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	}
+
+	// Other queues and other categories have their own buckets
+	waitImmediately(t, l, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_2"})
+	waitImmediately(t, l, "Moab", "Dequeue", &moab.DequeueRequest{QueueName: "queue_1"})
+
+	// Deadline before a token is available fails right away and does not consume a token
+	deadlineCtx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	start := time.Now()
+	err := l.Wait(deadlineCtx, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Millisecond*10)
+
+	// Next token in 100ms
+	start = time.Now()
+	err = l.Wait(ctx, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	require.NoError(t, err)
+	require.InDelta(t, time.Millisecond*100, time.Since(start), float64(time.Millisecond*50))
+
+	// Canceled while waiting
+	cancelCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+	err = l.Wait(cancelCtx, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLimiterCategories(t *testing.T) {
+	l := New(testLimits(1))
+
+	// Reads of a service share a bucket
+	waitImmediately(t, l, "Moab", "GetQueue", &moab.GetQueueRequest{QueueName: "queue_1"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err := l.Wait(ctx, "Moab", "ListQueues", &moab.ListQueuesRequest{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Other services have their own buckets
+	waitImmediately(t, l, "IAM", "GetRole", &iam.GetRoleRequest{})
+
+	// Calls without limits are never throttled
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Wait(ctx, "Moab", "GetTask", &moab.GetTaskRequest{}))
+		require.NoError(t, l.Wait(ctx, "IAM", "CreateRole", &iam.CreateRoleRequest{}))
+		require.NoError(t, l.Wait(ctx, "Grackle", "GetLock", &moab.GetQueueRequest{}))
+	}
+}
+
+func TestLimiterFetch(t *testing.T) {
+	client := &testAccountClient{limits: testLimits(1)}
+	l, err := Fetch(context.Background(), client)
+	require.NoError(t, err)
+
+	waitImmediately(t, l, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"}), context.DeadlineExceeded)
+
+	// Limit increase
+	client.limits = testLimits(1000)
+	require.NoError(t, l.Refresh(context.Background(), client))
+	time.Sleep(time.Millisecond * 10)
+	waitImmediately(t, l, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+
+	// Limits removed
+	client.limits = nil
+	require.NoError(t, l.Refresh(context.Background(), client))
+	for i := 0; i < 2000; i++ {
+		waitImmediately(t, l, "Moab", "Enqueue", &moab.EnqueueRequest{QueueName: "queue_1"})
+	}
+
+	client.err = errors.New("unavailable")
+	_, err = Fetch(context.Background(), client)
+	require.Error(t, err)
+	require.Error(t, l.Refresh(context.Background(), client))
+}
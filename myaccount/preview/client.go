@@ -7,9 +7,6 @@ import (
 	evrblk "github.com/evrblk/evrblk-go"
 	internal "github.com/evrblk/evrblk-go/internal"
 	grpc "google.golang.org/grpc"
-	insecure "google.golang.org/grpc/credentials/insecure"
-	"log"
-	"time"
 )
 
 type MyAccountApi interface {
@@ -19,12 +16,14 @@ type MyAccountGrpcClient struct {
 	grpc   MyAccountPreviewApiClient
 	conn   *grpc.ClientConn
 	signer evrblk.RequestSigner
+	config *evrblk.ClientConfig
 }
 
 var _ MyAccountApi = &MyAccountGrpcClient{}
 
 func (c *MyAccountGrpcClient) WithSigner(signer evrblk.RequestSigner) *MyAccountGrpcClient {
 	return &MyAccountGrpcClient{
+		config: c.config,
 		conn:   c.conn,
 		grpc:   c.grpc,
 		signer: signer,
@@ -36,30 +35,19 @@ func (c *MyAccountGrpcClient) Close() {
 }
 
 func (c *MyAccountGrpcClient) GetAccount(ctx context.Context, request *GetAccountRequest) (*GetAccountResponse, error) {
-	internal.TotalRequestsCounter.WithLabelValues("MyAccount", "GetAccount").Inc()
-	defer internal.MeasureSince(internal.RequestsDuration.WithLabelValues("MyAccount", "GetAccount"), time.Now())
-
-	signedCtx, err := c.signer.Sign(ctx, request, "MyAccount", "GetAccount")
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.grpc.GetAccount(signedCtx, request, grpc.WaitForReady(true))
-	if err != nil {
-		internal.FailedRequestsCounter.WithLabelValues("MyAccount", "GetAccount", internal.MetricLabelFromGrpcError(err)).Inc()
-	}
-
-	return resp, internal.ErrorFromRpcError(err)
+	return internal.Invoke(ctx, c.config, c.signer, "MyAccount", "GetAccount", request, true, evrblk.ControlPlaneMethod, c.grpc.GetAccount)
 }
 
-func NewMyAccountGrpcClient(address string, signer evrblk.RequestSigner) *MyAccountGrpcClient {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewMyAccountGrpcClient(address string, signer evrblk.RequestSigner, opts ...evrblk.ClientOption) (*MyAccountGrpcClient, error) {
+	config := evrblk.NewClientConfig(opts...)
+	conn, err := internal.Dial(address, config)
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return nil, err
 	}
 	return &MyAccountGrpcClient{
+		config: config,
 		conn:   conn,
 		grpc:   NewMyAccountPreviewApiClient(conn),
 		signer: signer,
-	}
+	}, nil
 }
@@ -0,0 +1,127 @@
+package evrblk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	presignedTokenKey = "evrblk-presigned-token"
+
+	defaultPresignedTTL = time.Minute * 5
+)
+
+type presignOptions struct {
+	clock Clock
+	ttl   time.Duration
+}
+
+// PresignOption configures presigned tokens
+type PresignOption func(*presignOptions)
+
+// WithPresignTTL sets how long a presigned token is valid (5 minutes by default)
+func WithPresignTTL(ttl time.Duration) PresignOption {
+	return func(o *presignOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithPresignClock sets a clock used to timestamp presigned tokens (system clock by default)
+func WithPresignClock(clock Clock) PresignOption {
+	return func(o *presignOptions) {
+		o.clock = clock
+	}
+}
+
+func newPresignOptions(opts []PresignOption) *presignOptions {
+	o := &presignOptions{
+		clock: NewSystemClock(),
+		ttl:   defaultPresignedTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Presign issues a self-contained token, signed by an Alfa or Bravo API key, which authorizes exactly one call of a
+// method of a service with a given request. The token can be given to a browser or an untrusted job, which makes the
+// call with NewPresignedSigner and never sees the API key.
+func Presign(apiKeyId string, apiSecretKey string, request proto.Message, service string, method string, opts ...PresignOption) (string, error) {
+	o := newPresignOptions(opts)
+
+	now := o.clock.Now()
+	claims, err := authn.NewPresignedClaims(apiKeyId, request, service, method, now.Unix(), now.Add(o.ttl).Unix())
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasPrefix(apiKeyId, alfaKeyPrefix):
+		privateKey, err := authn.ParseAlfaPrivateKey(apiSecretKey)
+		if err != nil {
+			return "", fmt.Errorf("invalid Alfa private key: %w", err)
+		}
+		return authn.IssueAlfaPresignedToken(claims, privateKey)
+
+	case strings.HasPrefix(apiKeyId, bravoKeyPrefix):
+		hashedSecret, err := authn.HashBravoSecretWithDate(apiSecretKey, authn.GetDateOfTimestamp(claims.IssuedAt))
+		if err != nil {
+			return "", fmt.Errorf("invalid Bravo secret: %w", err)
+		}
+		return authn.IssueBravoPresignedToken(claims, hashedSecret)
+
+	default:
+		return "", fmt.Errorf("presigned tokens can only be issued by Alfa and Bravo API keys: %s", apiKeyId)
+	}
+}
+
+// presignedSigner sends a presigned token instead of signing requests
+type presignedSigner struct {
+	token  string
+	claims authn.PresignedClaims
+}
+
+var _ RequestSigner = &presignedSigner{}
+var _ SingleUseSigner = &presignedSigner{}
+
+func (s *presignedSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
+	// Fail early, a server would reject the call anyway
+	err := s.claims.Matches(request, service, method)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, presignedTokenKey, s.token), nil
+}
+
+// SingleUse returns true, since a server accepts a presigned token only once
+func (s *presignedSigner) SingleUse() bool {
+	return true
+}
+
+// SingleUseSigner is an optional interface of RequestSigner implemented by signers whose signed calls are accepted by
+// servers only once (e.g. with presigned tokens). Generated clients never retry or hedge such calls.
+type SingleUseSigner interface {
+	SingleUse() bool
+}
+
+// NewPresignedSigner creates a request signer which authorizes a single call with a presigned token (see Presign).
+// Signing any other call fails. Servers must accept presigned tokens (see SignatureVerifier.WithPresignedTokens).
+func NewPresignedSigner(token string) (RequestSigner, error) {
+	presigned, err := authn.ParsePresignedToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &presignedSigner{
+		token:  token,
+		claims: presigned.Claims,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package evrblk
+
+import (
+	"math"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy defines how generated clients retry failed calls. Only idempotent calls are retried: Get* and List*
+// methods, and calls with a dedupe key on every entry (e.g. Moab Enqueue). Every attempt is signed again, so its
+// timestamp is fresh.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. 1 disables retries.
+	MaxAttempts int
+
+	// Backoff before n-th retry is a random duration between zero and
+	// min(MaxBackoff, InitialBackoff * Multiplier^(n-1)) ("full jitter"). If a server tells how long to wait
+	// (google.rpc.RetryInfo), backoff is not shorter than that.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Budget limits total time of all attempts and backoffs of a call. Zero means no limit besides context deadline.
+	Budget time.Duration
+
+	// RetryableCodes are gRPC status codes which are retried
+	RetryableCodes []codes.Code
+}
+
+// DefaultRetryPolicy makes up to 3 attempts within 10 seconds on Unavailable and ResourceExhausted errors. Clients do
+// not retry unless given WithRetryPolicy(DefaultRetryPolicy).
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond * 100,
+	MaxBackoff:     time.Second * 2,
+	Multiplier:     2,
+	Budget:         time.Second * 10,
+	RetryableCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+}
+
+// NoRetries makes a single attempt of every call
+var NoRetries = RetryPolicy{
+	MaxAttempts: 1,
+}
+
+// WithRetryPolicy sets a retry policy of a client (NoRetries by default)
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *ClientConfig) {
+		c.RetryPolicy = policy
+	}
+}
+
+// Retryable checks whether a call failed with a given code can be retried
+func (p *RetryPolicy) Retryable(code codes.Code) bool {
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the upper bound of backoff before n-th retry (starting from 1), before jitter
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	if backoff > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(backoff)
+}
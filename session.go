@@ -0,0 +1,189 @@
+package evrblk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	sessionTokenKey = "evrblk-session-token"
+
+	defaultSessionTTL = time.Hour
+)
+
+// Session is a short-lived credential delegated by a long-term API key. Its token and private key are given to
+// workers, which sign requests with NewSessionSigner, so the long-term key never leaves the host which issued the
+// session.
+type Session struct {
+	// Token is a session token signed by the long-term API key
+	Token string
+
+	// PrivateKeyPem is a private PEM of an Ed25519 session key pair, which requests are signed with
+	PrivateKeyPem string
+
+	// ExpiresAt is expiration time of the session, requests signed after it are rejected
+	ExpiresAt time.Time
+}
+
+type sessionOptions struct {
+	clock   Clock
+	ttl     time.Duration
+	methods []string
+}
+
+// SessionOption configures issued sessions
+type SessionOption func(*sessionOptions)
+
+// WithSessionTTL sets how long an issued session is valid (1 hour by default)
+func WithSessionTTL(ttl time.Duration) SessionOption {
+	return func(o *sessionOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithSessionMethods restricts an issued session to given services ("Moab") or methods ("Moab.GetQueue"). All
+// methods are allowed by default.
+func WithSessionMethods(methods ...string) SessionOption {
+	return func(o *sessionOptions) {
+		o.methods = methods
+	}
+}
+
+// WithSessionClock sets a clock used to timestamp issued sessions (system clock by default)
+func WithSessionClock(clock Clock) SessionOption {
+	return func(o *sessionOptions) {
+		o.clock = clock
+	}
+}
+
+func newSessionOptions(opts []SessionOption) *sessionOptions {
+	o := &sessionOptions{
+		clock: NewSystemClock(),
+		ttl:   defaultSessionTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// NewSession issues a new session with a fresh session key pair, signed by a long-term Alfa or Bravo API key.
+func NewSession(apiKeyId string, apiSecretKey string, opts ...SessionOption) (*Session, error) {
+	o := newSessionOptions(opts)
+
+	privatePem, publicPem, err := authn.GenerateAlfaEd25519Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	now := o.clock.Now()
+	claims := &authn.SessionClaims{
+		ApiKeyId:  apiKeyId,
+		PublicKey: publicPem,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(o.ttl).Unix(),
+		Methods:   o.methods,
+	}
+
+	var token string
+	switch {
+	case strings.HasPrefix(apiKeyId, alfaKeyPrefix):
+		privateKey, err := authn.ParseAlfaPrivateKey(apiSecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Alfa private key: %w", err)
+		}
+		token, err = authn.IssueAlfaSessionToken(claims, privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+	case strings.HasPrefix(apiKeyId, bravoKeyPrefix):
+		hashedSecret, err := authn.HashBravoSecretWithDate(apiSecretKey, authn.GetDateOfTimestamp(claims.IssuedAt))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Bravo secret: %w", err)
+		}
+		token, err = authn.IssueBravoSessionToken(claims, hashedSecret)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("sessions can only be issued by Alfa and Bravo API keys: %s", apiKeyId)
+	}
+
+	return &Session{
+		Token:         token,
+		PrivateKeyPem: privatePem,
+		ExpiresAt:     time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// sessionRequestSigner signs requests with a session key on behalf of the API key which issued the session, and
+// sends the session token with every request
+type sessionRequestSigner struct {
+	*alfaRequestSigner
+
+	token string
+}
+
+var _ RequestSigner = &sessionRequestSigner{}
+var _ ResponseObserver = &sessionRequestSigner{}
+var _ ClockSkewCorrector = &sessionRequestSigner{}
+var _ StreamSigner = &sessionRequestSigner{}
+
+func (s *sessionRequestSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
+	ctx, err := s.alfaRequestSigner.Sign(ctx, request, service, method)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, sessionTokenKey, s.token), nil
+}
+
+func (s *sessionRequestSigner) SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error) {
+	ctx, messageSigner, err := s.alfaRequestSigner.SignStream(ctx, service, method)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, sessionTokenKey, s.token), messageSigner, nil
+}
+
+// NewSessionSigner creates a new request signer which signs requests with a session (see Session). The token is not
+// verified, only a server can do it, but the private key must match the session.
+func NewSessionSigner(token string, privatePem string, opts ...SignerOption) (RequestSigner, error) {
+	o := newSignerOptions(opts)
+
+	session, err := authn.ParseSessionToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := authn.ParseAlfaPrivateKey(privatePem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session private key: %w", err)
+	}
+	publicKey, err := authn.ParseAlfaPublicKey(session.Claims.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session public key: %w", err)
+	}
+	if key, ok := privateKey.Public().(ed25519.PublicKey); !ok || !key.Equal(publicKey) {
+		return nil, errors.New("private key does not match the session")
+	}
+
+	return &sessionRequestSigner{
+		alfaRequestSigner: &alfaRequestSigner{
+			privateKey:    privateKey,
+			apiKeyId:      session.Claims.ApiKeyId,
+			clock:         newSkewCorrectedClock(o.clock, o.skewCorrection),
+			headerSigning: o.headerSigning,
+		},
+		token: token,
+	}, nil
+}
@@ -2,8 +2,13 @@ package evrblk
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/evrblk/evrblk-go/authn"
@@ -15,98 +20,450 @@ const (
 	signatureKey = "evrblk-signature"
 	apiKeyKey    = "evrblk-api-key-id"
 	timestampKey = "evrblk-timestamp"
+	versionKey   = "evrblk-signature-version"
+
+	// Headers of signature version 2
+	signedHeadersKey = "evrblk-signed-headers"
+	deadlineKey      = "evrblk-deadline"
+	nonceKey         = "evrblk-nonce"
+
+	alfaKeyPrefix    = "key_alfa_"
+	bravoKeyPrefix   = "key_bravo_"
+	charlieKeyPrefix = "key_charlie_"
 )
 
 type RequestSigner interface {
 	Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error)
 }
 
+type signerOptions struct {
+	clock          Clock
+	skewCorrection bool
+	headerSigning  headerSigning
+}
+
+// SignerOption configures a request signer
+type SignerOption func(*signerOptions)
+
+// WithClock sets a clock used to timestamp signed requests (system clock by default)
+func WithClock(clock Clock) SignerOption {
+	return func(o *signerOptions) {
+		o.clock = clock
+	}
+}
+
+// WithClockSkewCorrection enables or disables correction of local clock skew based on server time reported in
+// responses (enabled by default). When a request is rejected because of its timestamp, the signer learns the offset
+// between local and server time, applies it to subsequent signatures, and the call is retried once.
+func WithClockSkewCorrection(enabled bool) SignerOption {
+	return func(o *signerOptions) {
+		o.skewCorrection = enabled
+	}
+}
+
+// WithSignatureVersion2 switches signing to signature version 2 (see package authn), which also signs API key ID, a
+// random nonce, deadline of a call (if set) and given headers of outgoing metadata. Servers must support version 2.
+func WithSignatureVersion2(headers ...string) SignerOption {
+	return func(o *signerOptions) {
+		o.headerSigning = headerSigning{
+			enabled: true,
+			headers: headers,
+		}
+	}
+}
+
+func newSignerOptions(opts []SignerOption) *signerOptions {
+	o := &signerOptions{
+		clock:          NewSystemClock(),
+		skewCorrection: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 type alfaRequestSigner struct {
-	privatePem string
-	apiKeyId   string
+	privateKey    crypto.Signer
+	apiKeyId      string
+	clock         *skewCorrectedClock
+	headerSigning headerSigning
 }
 
 var _ RequestSigner = &alfaRequestSigner{}
+var _ ResponseObserver = &alfaRequestSigner{}
+var _ ClockSkewCorrector = &alfaRequestSigner{}
+var _ StreamSigner = &alfaRequestSigner{}
 
 func (s *alfaRequestSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
-	// Current time in Unix seconds
-	now := time.Now().Unix()
+	ctx, _, _, err := signRequest(ctx, s, s.apiKeyId, s.clock, s.headerSigning, request, service, method)
+	return ctx, err
+}
 
-	signature, err := authn.SignAlfa(now, s.privatePem, request, service, method)
-	if err != nil {
-		return nil, err
+func (s *alfaRequestSigner) SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error) {
+	return signStream(ctx, s, s.apiKeyId, s.clock, s.headerSigning, service, method)
+}
+
+func (s *alfaRequestSigner) signPayload(timestamp int64, request proto.Message, service string, method string, opts ...authn.SignOption) (string, error) {
+	return authn.SignAlfaWithKey(timestamp, s.privateKey, request, service, method, opts...)
+}
+
+func (s *alfaRequestSigner) ObserveResponse(header metadata.MD, err error) bool {
+	return s.clock.Observe(header, err)
+}
+
+func (s *alfaRequestSigner) ClockOffset() time.Duration {
+	return s.clock.Offset()
+}
+
+// NewAlfaRequestSigner creates a new request signer for Alfa API keys. The private key is parsed and validated once
+// here, not on every request.
+func NewAlfaRequestSigner(apiKeyId string, privatePem string, opts ...SignerOption) (RequestSigner, error) {
+	o := newSignerOptions(opts)
+
+	if !strings.HasPrefix(apiKeyId, alfaKeyPrefix) {
+		return nil, fmt.Errorf("not an Alfa API key: %s", apiKeyId)
 	}
 
-	// Add headers into request context
-	ctx = metadata.AppendToOutgoingContext(ctx,
-		apiKeyKey, s.apiKeyId, // API key ID
-		timestampKey, fmt.Sprintf("%d", now), // Current timestamp
-		signatureKey, signature) // Signature
+	privateKey, err := authn.ParseAlfaPrivateKey(privatePem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Alfa private key: %w", err)
+	}
 
-	return ctx, nil
+	return &alfaRequestSigner{
+		privateKey:    privateKey,
+		apiKeyId:      apiKeyId,
+		clock:         newSkewCorrectedClock(o.clock, o.skewCorrection),
+		headerSigning: o.headerSigning,
+	}, nil
 }
 
-// NewAlfaRequestSigner creates a new request signer for Alfa API keys.
-func NewAlfaRequestSigner(apiKeyId string, privatePem string) (RequestSigner, error) {
-	// TODO add validations: key is alfa, pem is valid
+// NewAlfaRequestSignerWithKey creates a new request signer for Alfa API keys backed by any crypto.Signer with a P-256
+// or Ed25519 key. The private key can live in an HSM, a PKCS#11 token or a KMS, the signer only computes a digest of
+// a request and encodes a signature.
+func NewAlfaRequestSignerWithKey(apiKeyId string, privateKey crypto.Signer, opts ...SignerOption) (RequestSigner, error) {
+	o := newSignerOptions(opts)
+
+	if !strings.HasPrefix(apiKeyId, alfaKeyPrefix) {
+		return nil, fmt.Errorf("not an Alfa API key: %s", apiKeyId)
+	}
+
+	err := authn.ValidateAlfaKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Alfa private key: %w", err)
+	}
+
 	return &alfaRequestSigner{
-		privatePem: privatePem,
-		apiKeyId:   apiKeyId,
+		privateKey:    privateKey,
+		apiKeyId:      apiKeyId,
+		clock:         newSkewCorrectedClock(o.clock, o.skewCorrection),
+		headerSigning: o.headerSigning,
 	}, nil
 }
 
 type bravoRequestSigner struct {
-	secret   string
-	apiKeyId string
+	secret        string
+	apiKeyId      string
+	clock         *skewCorrectedClock
+	headerSigning headerSigning
+	derivedKey    atomic.Pointer[bravoDerivedKey]
+}
+
+// bravoDerivedKey is a Bravo secret hashed with a date
+type bravoDerivedKey struct {
+	date         string
+	hashedSecret []byte
 }
 
 var _ RequestSigner = &bravoRequestSigner{}
+var _ ResponseObserver = &bravoRequestSigner{}
+var _ ClockSkewCorrector = &bravoRequestSigner{}
+var _ StreamSigner = &bravoRequestSigner{}
 
 func (s *bravoRequestSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
-	// Current time in Unix seconds
-	now := time.Now().Unix()
+	ctx, _, _, err := signRequest(ctx, s, s.apiKeyId, s.clock, s.headerSigning, request, service, method)
+	return ctx, err
+}
+
+func (s *bravoRequestSigner) SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error) {
+	return signStream(ctx, s, s.apiKeyId, s.clock, s.headerSigning, service, method)
+}
+
+func (s *bravoRequestSigner) signPayload(timestamp int64, request proto.Message, service string, method string, opts ...authn.SignOption) (string, error) {
+	hashedSecret, err := s.hashedSecret(timestamp)
+	if err != nil {
+		return "", err
+	}
+
+	return authn.SignBravoWithHashedSecret(timestamp, hashedSecret, request, service, method, opts...)
+}
 
-	signature, err := authn.SignBravo(now, s.secret, request, service, method)
+// hashedSecret returns the secret hashed with the date of timestamp. It is derived once per day, concurrent calls
+// around midnight may derive it more than once, but always return the key of their own date.
+func (s *bravoRequestSigner) hashedSecret(timestamp int64) ([]byte, error) {
+	date := authn.GetDateOfTimestamp(timestamp)
+
+	derivedKey := s.derivedKey.Load()
+	if derivedKey != nil && derivedKey.date == date {
+		return derivedKey.hashedSecret, nil
+	}
+
+	hashedSecret, err := authn.HashBravoSecretWithDate(s.secret, date)
 	if err != nil {
 		return nil, err
 	}
+	s.derivedKey.Store(&bravoDerivedKey{
+		date:         date,
+		hashedSecret: hashedSecret,
+	})
 
-	// Add headers into request context
-	ctx = metadata.AppendToOutgoingContext(ctx,
-		apiKeyKey, s.apiKeyId, // API key ID
-		timestampKey, fmt.Sprintf("%d", now), // Current timestamp
-		signatureKey, signature) // Signature
+	return hashedSecret, nil
+}
 
-	return ctx, nil
+func (s *bravoRequestSigner) ObserveResponse(header metadata.MD, err error) bool {
+	return s.clock.Observe(header, err)
+}
+
+func (s *bravoRequestSigner) ClockOffset() time.Duration {
+	return s.clock.Offset()
 }
 
 // NewBravoRequestSigner creates a new request signer for Bravo API keys.
-func NewBravoRequestSigner(apiKeyId string, apiSecretKey string) (RequestSigner, error) {
-	// TODO add validations: key is bravo, secret is valid
+func NewBravoRequestSigner(apiKeyId string, apiSecretKey string, opts ...SignerOption) (RequestSigner, error) {
+	o := newSignerOptions(opts)
+
+	if !strings.HasPrefix(apiKeyId, bravoKeyPrefix) {
+		return nil, fmt.Errorf("not a Bravo API key: %s", apiKeyId)
+	}
+
+	err := authn.ValidateBravoSecret(apiSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Bravo secret: %w", err)
+	}
+
 	return &bravoRequestSigner{
-		secret:   apiSecretKey,
-		apiKeyId: apiKeyId,
+		secret:        apiSecretKey,
+		apiKeyId:      apiKeyId,
+		clock:         newSkewCorrectedClock(o.clock, o.skewCorrection),
+		headerSigning: o.headerSigning,
+	}, nil
+}
+
+type charlieRequestSigner struct {
+	secret        string
+	apiKeyId      string
+	clock         *skewCorrectedClock
+	headerSigning headerSigning
+
+	mu          sync.Mutex
+	date        string
+	derivedKeys map[string][]byte
+}
+
+var _ RequestSigner = &charlieRequestSigner{}
+var _ ResponseObserver = &charlieRequestSigner{}
+var _ ClockSkewCorrector = &charlieRequestSigner{}
+var _ StreamSigner = &charlieRequestSigner{}
+
+func (s *charlieRequestSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
+	ctx, _, _, err := signRequest(ctx, s, s.apiKeyId, s.clock, s.headerSigning, request, service, method)
+	return ctx, err
+}
+
+func (s *charlieRequestSigner) SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error) {
+	return signStream(ctx, s, s.apiKeyId, s.clock, s.headerSigning, service, method)
+}
+
+func (s *charlieRequestSigner) signPayload(timestamp int64, request proto.Message, service string, method string, opts ...authn.SignOption) (string, error) {
+	hashedSecret, err := s.hashedSecret(timestamp, service)
+	if err != nil {
+		return "", err
+	}
+
+	return authn.SignCharlieWithHashedSecret(timestamp, hashedSecret, request, service, method, opts...)
+}
+
+// hashedSecret returns the secret derived for the date of timestamp and a service. Derived keys are cached until the
+// date changes.
+func (s *charlieRequestSigner) hashedSecret(timestamp int64, service string) ([]byte, error) {
+	date := authn.GetDateOfTimestamp(timestamp)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.date == date {
+		if hashedSecret, ok := s.derivedKeys[service]; ok {
+			return hashedSecret, nil
+		}
+	}
+
+	hashedSecret, err := authn.HashCharlieSecret(s.secret, date, service)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests around midnight may have timestamps of both dates, only keys of the latest date are cached
+	if date > s.date {
+		s.date = date
+		s.derivedKeys = make(map[string][]byte)
+	}
+	if date == s.date {
+		s.derivedKeys[service] = hashedSecret
+	}
+
+	return hashedSecret, nil
+}
+
+func (s *charlieRequestSigner) ObserveResponse(header metadata.MD, err error) bool {
+	return s.clock.Observe(header, err)
+}
+
+func (s *charlieRequestSigner) ClockOffset() time.Duration {
+	return s.clock.Offset()
+}
+
+// NewCharlieRequestSigner creates a new request signer for Charlie API keys.
+func NewCharlieRequestSigner(apiKeyId string, apiSecretKey string, opts ...SignerOption) (RequestSigner, error) {
+	o := newSignerOptions(opts)
+
+	if !strings.HasPrefix(apiKeyId, charlieKeyPrefix) {
+		return nil, fmt.Errorf("not a Charlie API key: %s", apiKeyId)
+	}
+
+	err := authn.ValidateCharlieSecret(apiSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Charlie secret: %w", err)
+	}
+
+	return &charlieRequestSigner{
+		secret:        apiSecretKey,
+		apiKeyId:      apiKeyId,
+		clock:         newSkewCorrectedClock(o.clock, o.skewCorrection),
+		headerSigning: o.headerSigning,
 	}, nil
 }
 
-// NewRequestSigner creates a new request signer for Alfa or Bravo API keys based on provided API key ID.
-func NewRequestSigner(apiKeyId string, apiSecretKey string) (RequestSigner, error) {
-	if strings.HasPrefix(apiKeyId, "key_alfa_") {
-		return NewAlfaRequestSigner(apiKeyId, apiSecretKey)
+// NewRequestSigner creates a new request signer for Alfa, Bravo or Charlie API keys based on a prefix of provided API
+// key ID.
+func NewRequestSigner(apiKeyId string, apiSecretKey string, opts ...SignerOption) (RequestSigner, error) {
+	switch {
+	case strings.HasPrefix(apiKeyId, alfaKeyPrefix):
+		return NewAlfaRequestSigner(apiKeyId, apiSecretKey, opts...)
+	case strings.HasPrefix(apiKeyId, bravoKeyPrefix):
+		return NewBravoRequestSigner(apiKeyId, apiSecretKey, opts...)
+	case strings.HasPrefix(apiKeyId, charlieKeyPrefix):
+		return NewCharlieRequestSigner(apiKeyId, apiSecretKey, opts...)
+	default:
+		return nil, fmt.Errorf("unknown API key type: %s", apiKeyId)
+	}
+}
+
+// payloadSigner signs payloads with a key of one of supported types
+type payloadSigner interface {
+	signPayload(timestamp int64, request proto.Message, service string, method string, opts ...authn.SignOption) (string, error)
+}
+
+// signRequest signs a request with current time and adds signature headers into outgoing metadata of ctx. Returns
+// the timestamp and the signature besides the context.
+func signRequest(ctx context.Context, p payloadSigner, apiKeyId string, clock *skewCorrectedClock, h headerSigning, request proto.Message, service string, method string) (context.Context, int64, string, error) {
+	// Current time in Unix seconds
+	now := clock.Now().Unix()
+
+	headers := h.collect(ctx, apiKeyId, clock)
+
+	signature, err := p.signPayload(now, request, service, method, headers.signOptions()...)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	// Add headers into request context
+	return headers.attach(ctx, apiKeyId, now, signature), now, signature, nil
+}
+
+// headerSigning is a configuration of signature version 2 shared by all signers
+type headerSigning struct {
+	enabled bool
+	headers []string
+}
+
+// signedHeaders are headers of one request signed with signature version 2 (nil for version 1)
+type signedHeaders map[string][]string
+
+// collect gathers headers to sign: API key ID, a random nonce, deadline of ctx in server time and configured headers of
+// outgoing metadata of ctx. The nonce makes every signature unique, so servers with a replay cache accept identical
+// requests signed within the same second (e.g. retries and hedged attempts).
+func (h headerSigning) collect(ctx context.Context, apiKeyId string, clock *skewCorrectedClock) signedHeaders {
+	if !h.enabled {
+		return nil
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	headers := signedHeaders{}
+	for _, name := range h.headers {
+		name = strings.ToLower(name)
+		headers[name] = md.Get(name)
+	}
+	headers[apiKeyKey] = []string{apiKeyId}
+	headers[nonceKey] = []string{rand.Text()}
+	if deadline, ok := ctx.Deadline(); ok {
+		headers[deadlineKey] = []string{strconv.FormatInt(deadline.Add(clock.Offset()).UnixMilli(), 10)}
+	}
+
+	return headers
+}
+
+func (h signedHeaders) signOptions() []authn.SignOption {
+	if h == nil {
+		return nil
+	}
+	return []authn.SignOption{authn.WithSignedHeaders(h)}
+}
+
+// attach adds signature headers into outgoing metadata of ctx
+func (h signedHeaders) attach(ctx context.Context, apiKeyId string, timestamp int64, signature string) context.Context {
+	kv := []string{
+		apiKeyKey, apiKeyId, // API key ID
+		timestampKey, fmt.Sprintf("%d", timestamp), // Current timestamp
+		signatureKey, signature, // Signature
+	}
+
+	if h == nil {
+		kv = append(kv, versionKey, authn.SignatureVersion1)
 	} else {
-		return NewBravoRequestSigner(apiKeyId, apiSecretKey)
+		kv = append(kv,
+			versionKey, authn.SignatureVersion2,
+			signedHeadersKey, strings.Join(authn.SignedHeaderNames(h), ","))
+		kv = append(kv, nonceKey, h[nonceKey][0])
+		if deadline, ok := h[deadlineKey]; ok {
+			kv = append(kv, deadlineKey, deadline[0])
+		}
 	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
 }
 
 type noOpSigner struct {
 }
 
 var _ RequestSigner = &noOpSigner{}
+var _ StreamSigner = &noOpSigner{}
 
 func (s *noOpSigner) Sign(ctx context.Context, request proto.Message, service string, method string) (context.Context, error) {
 	return ctx, nil
 }
 
+func (s *noOpSigner) SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error) {
+	return ctx, &noOpMessageSigner{}, nil
+}
+
+type noOpMessageSigner struct {
+}
+
+func (s *noOpMessageSigner) SignMessage(message proto.Message) (proto.Message, error) {
+	return message, nil
+}
+
 // NewNoOpSigner creates a new NoOp request signer
 func NewNoOpSigner() RequestSigner {
 	return &noOpSigner{}
@@ -0,0 +1,153 @@
+package evrblk
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// serverTimeKey is a response header with server time in Unix seconds
+	serverTimeKey = "evrblk-server-time"
+
+	// errorDomain is a domain of errdetails.ErrorInfo attached to errors
+	errorDomain = "evrblk.com"
+
+	// timestampOutOfRangeReason is a reason of errdetails.ErrorInfo attached to timestamp rejections, its metadata
+	// contains server time in Unix seconds under serverTimeDetail key
+	timestampOutOfRangeReason = "TIMESTAMP_OUT_OF_RANGE"
+	serverTimeDetail          = "server_time"
+
+	// apiKeyInvalidReason is a reason of errdetails.ErrorInfo attached to rejections of unknown or revoked API keys
+	apiKeyInvalidReason = "API_KEY_INVALID"
+
+	// clockSkewThreshold is the minimum difference between server time and local time which is corrected
+	clockSkewThreshold = time.Second * 10
+)
+
+// ResponseObserver is an optional interface of RequestSigner. Generated clients pass response headers and errors of
+// signed calls to it, and if it returns true, sign the same request again and retry the call once.
+type ResponseObserver interface {
+	ObserveResponse(header metadata.MD, err error) bool
+}
+
+// ClockSkewCorrector is implemented by request signers which correct clock skew of a local host based on server time
+// reported in responses.
+type ClockSkewCorrector interface {
+	// ClockOffset returns currently learned offset which is added to local time when signing requests.
+	ClockOffset() time.Duration
+}
+
+// skewCorrectedClock is a Clock with an offset learned from server responses
+type skewCorrectedClock struct {
+	clock   Clock
+	enabled bool
+	offset  atomic.Int64
+}
+
+var _ Clock = &skewCorrectedClock{}
+
+func newSkewCorrectedClock(clock Clock, enabled bool) *skewCorrectedClock {
+	return &skewCorrectedClock{
+		clock:   clock,
+		enabled: enabled,
+	}
+}
+
+func (c *skewCorrectedClock) Now() time.Time {
+	return c.clock.Now().Add(c.Offset())
+}
+
+func (c *skewCorrectedClock) Offset() time.Duration {
+	return time.Duration(c.offset.Load())
+}
+
+// Observe learns clock offset from a response. Returns true if a call was rejected because of its timestamp and the
+// offset was corrected, so the call can be signed again and retried.
+func (c *skewCorrectedClock) Observe(header metadata.MD, err error) bool {
+	if !c.enabled {
+		return false
+	}
+
+	if err != nil {
+		serverTime, ok := serverTimeFromError(err)
+		if !ok {
+			return false
+		}
+		return c.correct(serverTime)
+	}
+
+	serverTime, ok := serverTimeFromHeader(header)
+	if ok {
+		c.correct(serverTime)
+	}
+	return false
+}
+
+// correct sets a new offset if server time is too far from corrected local time. Returns true if offset was changed.
+func (c *skewCorrectedClock) correct(serverTime time.Time) bool {
+	now := c.clock.Now()
+	offset := c.Offset()
+
+	diff := serverTime.Sub(now.Add(offset))
+	if diff > -clockSkewThreshold && diff < clockSkewThreshold {
+		return false
+	}
+
+	return c.offset.CompareAndSwap(int64(offset), int64(serverTime.Sub(now)))
+}
+
+func serverTimeFromHeader(header metadata.MD) (time.Time, bool) {
+	values := header.Get(serverTimeKey)
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+	return parseUnixTime(values[0])
+}
+
+// serverTimeFromError extracts server time from a timestamp rejection error
+func serverTimeFromError(err error) (time.Time, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Unauthenticated {
+		return time.Time{}, false
+	}
+
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if ok && info.Domain == errorDomain && info.Reason == timestampOutOfRangeReason {
+			return parseUnixTime(info.Metadata[serverTimeDetail])
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// timestampOutOfRangeError creates an error for a rejected timestamp with server time attached, so the client can
+// correct its clock skew
+func timestampOutOfRangeError(err error, now time.Time) error {
+	st := status.Newf(codes.Unauthenticated, "invalid signature: %v", err)
+	st, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: timestampOutOfRangeReason,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			serverTimeDetail: strconv.FormatInt(now.Unix(), 10),
+		},
+	})
+	if detailsErr != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid signature: %v", err)
+	}
+	return st.Err()
+}
+
+func parseUnixTime(s string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
@@ -0,0 +1,149 @@
+package evrblk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Pseudo-headers signed with every message of a client or bidi stream
+	streamSequenceKey = "evrblk-stream-sequence"
+	streamPreviousKey = "evrblk-stream-previous"
+
+	// messageSignatureField is a field number which carries a signature of a stream message. It is the largest
+	// valid field number, so it never clashes with fields of Everblack messages.
+	messageSignatureField = protowire.Number(536870911)
+)
+
+// StreamSigner is an optional interface of RequestSigner for client- and bidi-streaming calls. A stream is signed
+// when it is opened (the same way as a request with an empty body), and every message sent over it is signed with
+// a signature chained to the previous one (see package authn).
+type StreamSigner interface {
+	// SignStream signs opening of a stream and returns a signer of its messages
+	SignStream(ctx context.Context, service string, method string) (context.Context, MessageSigner, error)
+}
+
+// MessageSigner signs messages of a single stream in order they are sent. It is returned by
+// StreamSigner.SignStream.
+type MessageSigner interface {
+	// SignMessage returns a copy of a message with its signature attached. Messages must be signed in order they are
+	// sent.
+	SignMessage(message proto.Message) (proto.Message, error)
+}
+
+// signStream signs opening of a stream with an empty request and returns a signer of its messages chained to the
+// signature of the opening
+func signStream(ctx context.Context, p payloadSigner, apiKeyId string, clock *skewCorrectedClock, h headerSigning, service string, method string) (context.Context, MessageSigner, error) {
+	ctx, timestamp, signature, err := signRequest(ctx, p, apiKeyId, clock, h, nil, service, method)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, &chainedMessageSigner{
+		signer:    p,
+		apiKeyId:  apiKeyId,
+		timestamp: timestamp,
+		service:   service,
+		method:    method,
+		previous:  signature,
+	}, nil
+}
+
+// chainedMessageSigner signs every message of a stream with the timestamp of the stream, its sequence number and
+// the signature of the previous message, so messages can't be dropped, reordered or replayed into another stream
+type chainedMessageSigner struct {
+	signer    payloadSigner
+	apiKeyId  string
+	timestamp int64
+	service   string
+	method    string
+
+	mu       sync.Mutex
+	sequence uint64
+	previous string
+}
+
+var _ MessageSigner = &chainedMessageSigner{}
+
+func (s *chainedMessageSigner) SignMessage(message proto.Message) (proto.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A message being resent must not carry a signature of its previous sending
+	signed := proto.Clone(message)
+	if _, err := takeMessageSignature(signed); err != nil {
+		return nil, err
+	}
+
+	sequence := s.sequence + 1
+	headers := streamMessageHeaders(s.apiKeyId, sequence, s.previous)
+	signature, err := s.signer.signPayload(s.timestamp, signed, s.service, s.method, authn.WithSignedHeaders(headers))
+	if err != nil {
+		return nil, err
+	}
+
+	setMessageSignature(signed, signature)
+	s.sequence = sequence
+	s.previous = signature
+
+	return signed, nil
+}
+
+// streamMessageHeaders returns pseudo-headers signed with a stream message
+func streamMessageHeaders(apiKeyId string, sequence uint64, previous string) map[string][]string {
+	return map[string][]string{
+		apiKeyKey:         {apiKeyId},
+		streamSequenceKey: {strconv.FormatUint(sequence, 10)},
+		streamPreviousKey: {previous},
+	}
+}
+
+// setMessageSignature appends a signature to unknown fields of a message
+func setMessageSignature(message proto.Message, signature string) {
+	m := message.ProtoReflect()
+	unknown := protowire.AppendTag(m.GetUnknown(), messageSignatureField, protowire.BytesType)
+	m.SetUnknown(protowire.AppendString(unknown, signature))
+}
+
+// takeMessageSignature removes a signature from unknown fields of a message and returns it. Returns an empty string
+// if a message is not signed, and an error if it has multiple or malformed signatures.
+func takeMessageSignature(message proto.Message) (string, error) {
+	m := message.ProtoReflect()
+	unknown := m.GetUnknown()
+
+	var signature string
+	var found bool
+	var rest []byte
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return "", protowire.ParseError(n)
+		}
+		fieldLen := protowire.ConsumeFieldValue(num, typ, unknown[n:])
+		if fieldLen < 0 {
+			return "", protowire.ParseError(fieldLen)
+		}
+
+		if num == messageSignatureField {
+			if typ != protowire.BytesType || found {
+				return "", errors.New("malformed message signature")
+			}
+			value, _ := protowire.ConsumeString(unknown[n:])
+			signature, found = value, true
+		} else {
+			rest = append(rest, unknown[:n+fieldLen]...)
+		}
+		unknown = unknown[n+fieldLen:]
+	}
+
+	if found {
+		m.SetUnknown(rest)
+	}
+	return signature, nil
+}
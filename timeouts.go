@@ -0,0 +1,81 @@
+package evrblk
+
+import (
+	"time"
+)
+
+// MethodCategory groups methods of generated clients by how long their calls are expected to take
+type MethodCategory int
+
+const (
+	// ControlPlaneMethod manages resources: Create*, Get*, Update*, Delete* and List* methods
+	ControlPlaneMethod MethodCategory = iota
+
+	// DataPlaneMethod works with data of resources, e.g. Moab Enqueue and Dequeue or Grackle AcquireLock
+	DataPlaneMethod
+
+	// LongPollMethod is held by a server until something happens, e.g. Grackle WaitAtBarrier
+	LongPollMethod
+)
+
+func (c MethodCategory) String() string {
+	switch c {
+	case ControlPlaneMethod:
+		return "control_plane"
+	case DataPlaneMethod:
+		return "data_plane"
+	case LongPollMethod:
+		return "long_poll"
+	default:
+		return "unknown"
+	}
+}
+
+// Timeouts are default timeouts of calls per method category. A default timeout covers all attempts of a call and is
+// applied only when the context of a call has no deadline. Zero means no default timeout.
+type Timeouts struct {
+	ControlPlane time.Duration
+	DataPlane    time.Duration
+	LongPoll     time.Duration
+}
+
+// DefaultTimeouts are 30 seconds for control plane and data plane calls, and 5 minutes for long polls. Clients do not
+// apply them unless given WithTimeouts(DefaultTimeouts).
+var DefaultTimeouts = Timeouts{
+	ControlPlane: time.Second * 30,
+	DataPlane:    time.Second * 30,
+	LongPoll:     time.Minute * 5,
+}
+
+// NoTimeouts disables default timeouts, calls are only limited by deadlines of their contexts
+var NoTimeouts = Timeouts{}
+
+// WithTimeouts sets default timeouts of a client (NoTimeouts by default)
+func WithTimeouts(timeouts Timeouts) ClientOption {
+	return func(c *ClientConfig) {
+		c.Timeouts = timeouts
+	}
+}
+
+// WithWaitForReady sets whether calls wait for a connection to become ready (true by default). When disabled, calls
+// fail right away with InternalFailure error code while a server is unreachable, instead of waiting until the context
+// is done.
+func WithWaitForReady(waitForReady bool) ClientOption {
+	return func(c *ClientConfig) {
+		c.WaitForReady = waitForReady
+	}
+}
+
+// Timeout returns a default timeout of calls of a given method category
+func (t *Timeouts) Timeout(category MethodCategory) time.Duration {
+	switch category {
+	case ControlPlaneMethod:
+		return t.ControlPlane
+	case DataPlaneMethod:
+		return t.DataPlane
+	case LongPollMethod:
+		return t.LongPoll
+	default:
+		return 0
+	}
+}
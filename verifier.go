@@ -0,0 +1,706 @@
+package evrblk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evrblk/evrblk-go/authn"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// KeyLookup resolves API key IDs into key material used to verify request signatures. If a lookup returns an error
+// which is already a gRPC status, it is passed to the caller as is, any other error is reported as Unauthenticated.
+type KeyLookup interface {
+	// AlfaPublicKey returns public PEM of an Alfa API key.
+	AlfaPublicKey(ctx context.Context, apiKeyId string) (string, error)
+
+	// BravoHashedSecret returns a secret of a Bravo API key hashed with a given date (see
+	// authn.HashBravoSecretWithDate).
+	BravoHashedSecret(ctx context.Context, apiKeyId string, date string) ([]byte, error)
+
+	// CharlieHashedSecret returns a secret of a Charlie API key derived for a given date and service (see
+	// authn.HashCharlieSecret).
+	CharlieHashedSecret(ctx context.Context, apiKeyId string, date string, service string) ([]byte, error)
+}
+
+// SignatureVerifier verifies signatures of incoming requests made with RequestSigner. It is a server-side
+// counterpart of RequestSigner and can be installed into a gRPC server with UnaryServerInterceptor and
+// StreamServerInterceptor.
+type SignatureVerifier struct {
+	keys            KeyLookup
+	services        map[string]string
+	replayCache     authn.ReplayCache
+	clock           Clock
+	verifyOptions   []authn.VerifyOption
+	requiredHeaders []string
+	presigned       bool
+	presignedCache  authn.ReplayCache
+}
+
+// NewSignatureVerifier creates a new signature verifier. Services map full gRPC service names (for example,
+// "com.evrblk.moab.preview.MoabPreviewApi") to service names used in signatures (for example, "Moab"). Calls to
+// services which are not in the map are rejected.
+func NewSignatureVerifier(keys KeyLookup, services map[string]string) *SignatureVerifier {
+	return &SignatureVerifier{
+		keys:     keys,
+		services: services,
+		clock:    NewSystemClock(),
+	}
+}
+
+// WithReplayCache returns a copy of the verifier which rejects signed requests already seen by a given replay cache.
+// Requests must be signed with signature version 2 with a signed evrblk-nonce header (see WithSignatureVersion2),
+// otherwise identical requests signed within the same second could not be told apart from replays.
+func (v *SignatureVerifier) WithReplayCache(replayCache authn.ReplayCache) *SignatureVerifier {
+	c := *v
+	c.replayCache = replayCache
+	return &c
+}
+
+// WithClock returns a copy of the verifier which checks request timestamps against a given clock.
+func (v *SignatureVerifier) WithClock(clock Clock) *SignatureVerifier {
+	c := *v
+	c.clock = clock
+	return &c
+}
+
+// WithVerifyOptions returns a copy of the verifier with given verification options (e.g. allowed clock skew, see
+// authn.WithMaxPastSkew and authn.WithMaxFutureSkew).
+func (v *SignatureVerifier) WithVerifyOptions(opts ...authn.VerifyOption) *SignatureVerifier {
+	c := *v
+	c.verifyOptions = opts
+	return &c
+}
+
+// WithRequiredSignedHeaders returns a copy of the verifier which only accepts requests signed with signature version
+// 2 with given headers signed (in addition to evrblk-api-key-id, which is always signed in version 2). Until then
+// both versions are accepted, so servers can migrate gradually.
+func (v *SignatureVerifier) WithRequiredSignedHeaders(headers ...string) *SignatureVerifier {
+	c := *v
+	c.requiredHeaders = append([]string{apiKeyKey}, headers...)
+	return &c
+}
+
+// WithPresignedTokens returns a copy of the verifier which also accepts calls authorized with presigned tokens (see
+// Presign) sent in evrblk-presigned-token header instead of a signature. A presigned token authorizes a single call,
+// so every token is stored in a given replay cache and accepted only once.
+func (v *SignatureVerifier) WithPresignedTokens(replayCache authn.ReplayCache) *SignatureVerifier {
+	c := *v
+	c.presigned = true
+	c.presignedCache = replayCache
+	return &c
+}
+
+// Verify checks a signature of a request. Signature headers are taken from incoming gRPC metadata of ctx. Returned
+// error is a gRPC status, with codes.Unauthenticated if a signature is missing or invalid.
+func (v *SignatureVerifier) Verify(ctx context.Context, request proto.Message, service string, method string) error {
+	_, err := v.verify(ctx, request, service, method)
+	return err
+}
+
+// verifiedRequest is what a valid signature proves about a request besides its body
+type verifiedRequest struct {
+	// deadline is a signed deadline of a call, zero if not signed
+	deadline time.Time
+
+	// nonce is a signed nonce of a request, empty if not signed
+	nonce string
+
+	// Key, timestamp and signature of a request, messages of client and bidi streams are chained to them
+	key       *verificationKey
+	timestamp int64
+	signature string
+}
+
+func (v *SignatureVerifier) verify(ctx context.Context, request proto.Message, service string, method string) (*verifiedRequest, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing request metadata")
+	}
+
+	if v.presigned && len(md.Get(presignedTokenKey)) > 0 {
+		token, err := singleHeader(md, presignedTokenKey)
+		if err != nil {
+			return nil, err
+		}
+		err = v.VerifyPresigned(ctx, token, request, service, method)
+		if err != nil {
+			return nil, err
+		}
+		return &verifiedRequest{}, nil
+	}
+
+	apiKeyId, err := singleHeader(md, apiKeyKey)
+	if err != nil {
+		return nil, err
+	}
+	timestampStr, err := singleHeader(md, timestampKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := singleHeader(md, signatureKey)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid %s header", timestampKey)
+	}
+
+	verified, headerOptions, err := v.verifyHeaders(md)
+	if err != nil {
+		return nil, err
+	}
+	verifyOptions := append(append([]authn.VerifyOption{}, v.verifyOptions...), headerOptions...)
+
+	// Identical requests signed within the same second are told apart from replays only by a signed nonce
+	if v.replayCache != nil && verified.nonce == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "signed %s header is required", nonceKey)
+	}
+
+	now := v.clock.Now()
+
+	var key *verificationKey
+	if len(md.Get(sessionTokenKey)) > 0 {
+		key, err = v.lookupSessionKey(ctx, md, apiKeyId, service, method, now)
+	} else {
+		key, err = v.lookupKey(ctx, apiKeyId, timestamp, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = key.verify(signature, timestamp, now, request, service, method, verifyOptions...)
+	if err != nil {
+		return nil, signatureError(err, now)
+	}
+
+	// Signature is valid, check that it is not a replay of a previous request. Requests are identified by signed data,
+	// since different encodings of the same signature all verify.
+	if v.replayCache != nil {
+		digest, err := authn.RequestDigest(timestamp, request, service, method, verifyOptions...)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "replay cache: %v", err)
+		}
+		err = v.replayCache.CheckAndStore(ctx, apiKeyId, timestamp, digest, now, authn.ReplayExpiration(timestamp, v.verifyOptions...))
+		if errors.Is(err, authn.ErrReplayedSignature) {
+			return nil, status.Error(codes.Unauthenticated, "replayed signature")
+		} else if err != nil {
+			return nil, status.Errorf(codes.Internal, "replay cache: %v", err)
+		}
+	}
+
+	verified.key = key
+	verified.timestamp = timestamp
+	verified.signature = signature
+
+	return verified, nil
+}
+
+// lookupKey resolves key material of an API key to verify a signature made at a given timestamp for a given service
+func (v *SignatureVerifier) lookupKey(ctx context.Context, apiKeyId string, timestamp int64, service string) (*verificationKey, error) {
+	key := &verificationKey{apiKeyId: apiKeyId}
+
+	var err error
+	switch {
+	case strings.HasPrefix(apiKeyId, alfaKeyPrefix):
+		key.publicPem, err = v.keys.AlfaPublicKey(ctx, apiKeyId)
+	case strings.HasPrefix(apiKeyId, bravoKeyPrefix):
+		key.hashedSecret, err = v.keys.BravoHashedSecret(ctx, apiKeyId, authn.GetDateOfTimestamp(timestamp))
+	case strings.HasPrefix(apiKeyId, charlieKeyPrefix):
+		key.hashedSecret, err = v.keys.CharlieHashedSecret(ctx, apiKeyId, authn.GetDateOfTimestamp(timestamp), service)
+	default:
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported API key type: %s", apiKeyId)
+	}
+	if err != nil {
+		return nil, lookupError(apiKeyId, err)
+	}
+
+	return key, nil
+}
+
+// lookupSessionKey verifies a session token sent with a request against the API key which issued it, and returns the
+// session key, which the request must be signed with
+func (v *SignatureVerifier) lookupSessionKey(ctx context.Context, md metadata.MD, apiKeyId string, service string, method string, now time.Time) (*verificationKey, error) {
+	token, err := singleHeader(md, sessionTokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := authn.ParseSessionToken(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid session token: %v", err)
+	}
+	if session.Claims.ApiKeyId != apiKeyId {
+		return nil, status.Error(codes.Unauthenticated, "session token is issued by another API key")
+	}
+
+	err = v.verifyToken(ctx, apiKeyId, session.Claims.IssuedAt, session)
+	if err != nil {
+		return nil, err
+	}
+
+	err = session.Claims.CheckExpiration(now)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "session expired")
+	}
+	if !session.Claims.Allows(service, method) {
+		return nil, status.Errorf(codes.PermissionDenied, "session does not allow %s.%s", service, method)
+	}
+
+	return &verificationKey{
+		apiKeyId:  apiKeyId,
+		publicPem: session.Claims.PublicKey,
+	}, nil
+}
+
+// VerifyPresigned checks that a call is authorized by a presigned token (see Presign): the token is signed by a known
+// API key, not expired, issued for exactly this method and request, and not used before (see WithPresignedTokens).
+// Returned error is a gRPC status.
+func (v *SignatureVerifier) VerifyPresigned(ctx context.Context, token string, request proto.Message, service string, method string) error {
+	// Without a replay cache a token could be used any number of times until it expires
+	if v.presignedCache == nil {
+		return status.Error(codes.Unauthenticated, "presigned tokens are not accepted without a replay cache")
+	}
+
+	presigned, err := authn.ParsePresignedToken(token)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid presigned token: %v", err)
+	}
+
+	err = v.verifyToken(ctx, presigned.Claims.ApiKeyId, presigned.Claims.IssuedAt, presigned)
+	if err != nil {
+		return err
+	}
+
+	now := v.clock.Now()
+	err = presigned.Claims.CheckExpiration(now)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "presigned token expired")
+	}
+	err = presigned.Claims.Matches(request, service, method)
+	if errors.Is(err, authn.ErrPresignedMismatch) {
+		return status.Error(codes.PermissionDenied, "presigned token does not allow this request")
+	} else if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	// A token is identified by its signed nonce rather than as sent, since an ECDSA signature can be malleated into a
+	// different token which still verifies
+	digest := "presigned:" + presigned.Claims.Nonce
+	err = v.presignedCache.CheckAndStore(ctx, presigned.Claims.ApiKeyId, presigned.Claims.IssuedAt, digest, now, time.Unix(presigned.Claims.ExpiresAt, 0))
+	if errors.Is(err, authn.ErrReplayedSignature) {
+		return status.Error(codes.Unauthenticated, "presigned token already used")
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "replay cache: %v", err)
+	}
+
+	return nil
+}
+
+// delegationToken is a session or presigned token signed by a long-term API key
+type delegationToken interface {
+	VerifyAlfa(publicPem string) error
+	VerifyBravo(hashedSecret []byte) error
+}
+
+// verifyToken verifies a signature of a session or presigned token issued by an API key at a given time
+func (v *SignatureVerifier) verifyToken(ctx context.Context, apiKeyId string, issuedAt int64, token delegationToken) error {
+	var err error
+	switch {
+	case strings.HasPrefix(apiKeyId, alfaKeyPrefix):
+		publicPem, lookupErr := v.keys.AlfaPublicKey(ctx, apiKeyId)
+		if lookupErr != nil {
+			return lookupError(apiKeyId, lookupErr)
+		}
+		err = token.VerifyAlfa(publicPem)
+
+	case strings.HasPrefix(apiKeyId, bravoKeyPrefix):
+		hashedSecret, lookupErr := v.keys.BravoHashedSecret(ctx, apiKeyId, authn.GetDateOfTimestamp(issuedAt))
+		if lookupErr != nil {
+			return lookupError(apiKeyId, lookupErr)
+		}
+		err = token.VerifyBravo(hashedSecret)
+
+	default:
+		return status.Errorf(codes.Unauthenticated, "tokens are not supported for API key %s", apiKeyId)
+	}
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid token signature: %v", err)
+	}
+
+	return nil
+}
+
+// verificationKey is key material of an API key: a public key of an Alfa key or of a session, or a hashed secret of a
+// Bravo or Charlie key
+type verificationKey struct {
+	apiKeyId     string
+	publicPem    string
+	hashedSecret []byte
+}
+
+func (k *verificationKey) verify(signature string, timestamp int64, now time.Time, request proto.Message, service string, method string, opts ...authn.VerifyOption) error {
+	switch {
+	case k.publicPem != "":
+		return authn.VerifyAlfaSignature(signature, timestamp, now, k.publicPem, request, service, method, opts...)
+	case strings.HasPrefix(k.apiKeyId, bravoKeyPrefix):
+		return authn.VerifyBravoSignature(signature, timestamp, now, k.hashedSecret, request, service, method, opts...)
+	default:
+		return authn.VerifyCharlieSignature(signature, timestamp, now, k.hashedSecret, request, service, method, opts...)
+	}
+}
+
+// verifyHeaders checks a signature version of a request, and for version 2 collects signed headers to be verified
+// along with the request
+func (v *SignatureVerifier) verifyHeaders(md metadata.MD) (*verifiedRequest, []authn.VerifyOption, error) {
+	// Clients older than signature versioning do not send the header
+	version := authn.SignatureVersion1
+	if len(md.Get(versionKey)) > 0 {
+		var err error
+		version, err = singleHeader(md, versionKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch version {
+	case authn.SignatureVersion1:
+		if len(v.requiredHeaders) > 0 {
+			return nil, nil, status.Errorf(codes.Unauthenticated, "signature version %s is required", authn.SignatureVersion2)
+		}
+		return &verifiedRequest{}, nil, nil
+
+	case authn.SignatureVersion2:
+		list, err := singleHeader(md, signedHeadersKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		names, err := authn.ParseSignedHeaderNames(list)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Unauthenticated, "invalid %s header: %v", signedHeadersKey, err)
+		}
+
+		headers := make(map[string][]string, len(names))
+		for _, name := range names {
+			headers[name] = md.Get(name)
+		}
+		for _, name := range append([]string{apiKeyKey}, v.requiredHeaders...) {
+			if _, ok := headers[name]; !ok {
+				return nil, nil, status.Errorf(codes.Unauthenticated, "%s header must be signed", name)
+			}
+		}
+
+		verified := &verifiedRequest{}
+		if _, ok := headers[deadlineKey]; ok {
+			deadline, err := singleHeader(md, deadlineKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			deadlineMillis, err := strconv.ParseInt(deadline, 10, 64)
+			if err != nil {
+				return nil, nil, status.Errorf(codes.Unauthenticated, "invalid %s header", deadlineKey)
+			}
+			verified.deadline = time.UnixMilli(deadlineMillis)
+		}
+		if _, ok := headers[nonceKey]; ok {
+			verified.nonce, err = singleHeader(md, nonceKey)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return verified, []authn.VerifyOption{authn.WithVerifiedHeaders(headers)}, nil
+
+	default:
+		return nil, nil, status.Errorf(codes.Unauthenticated, "unsupported signature version: %s", version)
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor which verifies signatures of unary calls.
+func (v *SignatureVerifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service, method, err := v.resolveMethod(info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		request, ok := req.(proto.Message)
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "request of %s is not a proto message", info.FullMethod)
+		}
+
+		verified, err := v.verify(ctx, request, service, method)
+		if err != nil {
+			return nil, err
+		}
+
+		// Report server time, so clients can correct their clock skew. It fails only outside of a gRPC server.
+		_ = grpc.SetHeader(ctx, v.serverTimeHeader())
+
+		ctx, cancel := withSignedDeadline(ctx, verified)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor which verifies signatures of streaming calls. Server-streaming
+// calls are checked when opened: the single request is received and verified before a handler is called, so request
+// descriptors of server-streaming methods must be registered in protoregistry (generated code does that).
+// Client- and bidi-streaming calls are checked when opened, and then every received message is checked against its
+// chained signature (see StreamSigner).
+func (v *SignatureVerifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method, err := v.resolveMethod(info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		if !info.IsClientStream {
+			request, err := newStreamRequest(info.FullMethod)
+			if err != nil {
+				return err
+			}
+			err = ss.RecvMsg(request)
+			if err != nil {
+				return err
+			}
+
+			verified, err := v.verify(ss.Context(), request, service, method)
+			if err != nil {
+				return err
+			}
+
+			// Report server time, so clients can correct their clock skew
+			_ = ss.SetHeader(v.serverTimeHeader())
+
+			ctx, cancel := withSignedDeadline(ss.Context(), verified)
+			defer cancel()
+
+			return handler(srv, &verifiedServerStream{
+				ServerStream: ss,
+				ctx:          ctx,
+				request:      request,
+			})
+		}
+
+		// Opening of a stream is signed as a request with an empty body
+		verified, err := v.verify(ss.Context(), nil, service, method)
+		if err != nil {
+			return err
+		}
+		if verified.key == nil {
+			return status.Error(codes.Unauthenticated, "presigned tokens can't open client streams")
+		}
+
+		// Report server time, so clients can correct their clock skew
+		_ = ss.SetHeader(v.serverTimeHeader())
+
+		ctx, cancel := withSignedDeadline(ss.Context(), verified)
+		defer cancel()
+
+		return handler(srv, &chainedServerStream{
+			ServerStream:  ss,
+			ctx:           ctx,
+			verifyOptions: v.verifyOptions,
+			service:       service,
+			method:        method,
+			verified:      verified,
+			previous:      verified.signature,
+		})
+	}
+}
+
+// resolveMethod splits full gRPC method name (/package.Service/Method) into service name used in signatures and
+// method name
+func (v *SignatureVerifier) resolveMethod(fullMethod string) (string, string, error) {
+	s := strings.Split(strings.TrimPrefix(fullMethod, "/"), "/")
+	if len(s) != 2 {
+		return "", "", status.Errorf(codes.Unauthenticated, "malformed method name: %s", fullMethod)
+	}
+
+	service, ok := v.services[s[0]]
+	if !ok {
+		return "", "", status.Errorf(codes.Unauthenticated, "unknown service: %s", s[0])
+	}
+
+	return service, s[1], nil
+}
+
+func (v *SignatureVerifier) serverTimeHeader() metadata.MD {
+	return metadata.Pairs(serverTimeKey, strconv.FormatInt(v.clock.Now().Unix(), 10))
+}
+
+// withSignedDeadline limits a context with a signed deadline of a request, so it cannot be extended by tampering with
+// gRPC timeout
+func withSignedDeadline(ctx context.Context, verified *verifiedRequest) (context.Context, context.CancelFunc) {
+	if verified.deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, verified.deadline)
+}
+
+// newStreamRequest creates an empty request message of a server-streaming method, resolved by its full gRPC name
+// (/package.Service/Method) in the global proto registry
+func newStreamRequest(fullMethod string) (proto.Message, error) {
+	name := protoreflect.FullName(strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown method %s: %v", fullMethod, err)
+	}
+	methodDesc, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unknown method %s", fullMethod)
+	}
+
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(methodDesc.Input().FullName())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown request type of %s: %v", fullMethod, err)
+	}
+	return messageType.New().Interface(), nil
+}
+
+// verifiedServerStream passes a request of a server-streaming call, received and verified by the interceptor, to a
+// handler
+type verifiedServerStream struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	request proto.Message
+}
+
+func (s *verifiedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *verifiedServerStream) RecvMsg(m any) error {
+	if s.request == nil {
+		return s.ServerStream.RecvMsg(m)
+	}
+
+	message, ok := m.(proto.Message)
+	if !ok || message.ProtoReflect().Descriptor() != s.request.ProtoReflect().Descriptor() {
+		return status.Errorf(codes.Internal, "unexpected request type %T", m)
+	}
+	proto.Reset(message)
+	proto.Merge(message, s.request)
+	s.request = nil
+
+	return nil
+}
+
+// chainedServerStream verifies chained signatures of messages of a client or bidi stream and removes them before
+// messages are passed to a handler
+type chainedServerStream struct {
+	grpc.ServerStream
+
+	ctx           context.Context
+	verifyOptions []authn.VerifyOption
+	service       string
+	method        string
+	verified      *verifiedRequest
+	sequence      uint64
+	previous      string
+}
+
+func (s *chainedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *chainedServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+
+	message, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "request of %s.%s is not a proto message", s.service, s.method)
+	}
+
+	signature, err := takeMessageSignature(message)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid message signature: %v", err)
+	}
+	if signature == "" {
+		return status.Error(codes.Unauthenticated, "missing message signature")
+	}
+
+	// Messages are signed with the timestamp of the stream, which is already checked, so the stream can last longer
+	// than allowed clock skew
+	sequence := s.sequence + 1
+	headers := streamMessageHeaders(s.verified.key.apiKeyId, sequence, s.previous)
+	opts := append(append([]authn.VerifyOption{}, s.verifyOptions...), authn.WithVerifiedHeaders(headers))
+	now := time.Unix(s.verified.timestamp, 0)
+	err = s.verified.key.verify(signature, s.verified.timestamp, now, message, s.service, s.method, opts...)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid message signature: %v", err)
+	}
+
+	s.sequence = sequence
+	s.previous = signature
+
+	return nil
+}
+
+func singleHeader(md metadata.MD, key string) (string, error) {
+	values := md.Get(key)
+	if len(values) == 0 || values[0] == "" {
+		return "", status.Errorf(codes.Unauthenticated, "missing %s header", key)
+	}
+	if len(values) > 1 {
+		return "", status.Errorf(codes.Unauthenticated, "multiple %s headers", key)
+	}
+	return values[0], nil
+}
+
+func signatureError(err error, now time.Time) error {
+	if errors.Is(err, authn.ErrTimestampOutOfRange) {
+		return timestampOutOfRangeError(err, now)
+	}
+	return status.Errorf(codes.Unauthenticated, "invalid signature: %v", err)
+}
+
+func lookupError(apiKeyId string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	st := status.Newf(codes.Unauthenticated, "unknown API key %s: %v", apiKeyId, err)
+	st, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: apiKeyInvalidReason,
+		Domain: errorDomain,
+	})
+	if detailsErr != nil {
+		return status.Errorf(codes.Unauthenticated, "unknown API key %s: %v", apiKeyId, err)
+	}
+	return st.Err()
+}
+
+// IsApiKeyInvalid checks whether a call was rejected because its API key is unknown to the server (e.g. a new key has
+// not propagated yet, or a key has been deleted), as opposed to other authentication failures
+func IsApiKeyInvalid(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Unauthenticated {
+		return false
+	}
+
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if ok && info.Domain == errorDomain && info.Reason == apiKeyInvalidReason {
+			return true
+		}
+	}
+	return false
+}